@@ -1,12 +1,13 @@
 package alerts
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"net"
 	"net/url"
 	"os"
+	"path"
 	"strings"
 	"time"
 
@@ -96,7 +97,7 @@ func ProcessAndForwardAlertEvent(triggeredInstance splunkalerts.EntryItem, logge
 		ProblemID:      "",
 		ProblemTitle:   alertDetails[3], //name of sli
 		ProblemDetails: json.RawMessage(`{}`),
-		ProblemURL:     net.JoinHostPort(client.Host, client.Port) + triggeredInstance.Links.Job + "/results",
+		ProblemURL:     problemURL(client, triggeredInstance, envConfig.SplunkWebURL),
 		ImpactedEntity: fmt.Sprintf("%s-%s", alertDetails[2], deploymentType),
 		Project:        alertDetails[0],
 		Stage:          alertDetails[1],
@@ -112,7 +113,7 @@ func ProcessAndForwardAlertEvent(triggeredInstance splunkalerts.EntryItem, logge
 			Stage:   alertDetails[1],
 			Service: alertDetails[2],
 			Labels: map[string]string{
-				"Problem URL": net.JoinHostPort(client.Host, client.Port) + triggeredInstance.Links.Job + "/results",
+				"Problem URL": problemURL(client, triggeredInstance, envConfig.SplunkWebURL),
 			},
 		},
 		Problem: problemData,
@@ -138,6 +139,31 @@ func ProcessAndForwardAlertEvent(triggeredInstance splunkalerts.EntryItem, logge
 
 }
 
+// return the link to the results of the job of the triggered alert, in splunk web if its url is set, otherwise in the REST API
+func problemURL(client *splunk.SplunkClient, triggeredInstance splunkalerts.EntryItem, webURL string) string {
+	if webURL == "" {
+		return splunk.CreateEndpoint(client, triggeredInstance.Links.Job+"/results")
+	}
+	sid := triggeredInstance.Content.Sid
+	if sid == "" {
+		sid = path.Base(triggeredInstance.Links.Job)
+	}
+	return utils.JobLink(webURL, jobApp(triggeredInstance.Links.Job), sid)
+}
+
+// return the app of the job from its link, e.g. search for /servicesNS/admin/search/search/jobs/{sid}, empty if the link has no namespace
+func jobApp(jobLink string) string {
+	segments := strings.Split(strings.Trim(jobLink, "/"), "/")
+	if len(segments) < 3 || segments[0] != "servicesNS" {
+		return ""
+	}
+	app, err := url.PathUnescape(segments[2])
+	if err != nil {
+		return ""
+	}
+	return app
+}
+
 // createAndSendCE create a new problem.triggered event and send it to Keptn
 func createAndSendCE(problemData RemediationTriggeredEventData, shkeptncontext string, ddKeptn *keptnv2.Keptn, keptnOptions keptn.KeptnOpts, envConfig utils.EnvConfig) error {
 	source, _ := url.Parse("splunk")
@@ -219,17 +245,23 @@ func isTestKeptn(i interface{}) bool {
 	}
 }
 
-// FiringAlertsPoll will handle all requests for '/health' and '/ready'
-func FiringAlertsPoll(client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptnOptions keptn.KeptnOpts, envConfig utils.EnvConfig) {
+// FiringAlertsPoll periodically checks splunk for fired keptn alerts until ctx is cancelled
+func FiringAlertsPoll(ctx context.Context, client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptnOptions keptn.KeptnOpts, envConfig utils.EnvConfig) {
 
 	shkeptncontext := uuid.New().String()
 	logger := keptn.NewLogger(shkeptncontext, "", serviceName)
 
+	// the alerts may be spread in the namespaces of the projects
+	ctx = utils.WithAlertsNamespace(ctx, envConfig)
+
 	for {
 
+		// each polling round must not outlive the next one
+		pollCtx, cancel := context.WithTimeout(ctx, pollingFrequency*time.Second)
+
 		//listing fired alerts
 		logger.Info("Searching for triggered alerts ...")
-		triggeredAlerts, err := splunkalerts.GetTriggeredAlerts(client)
+		triggeredAlerts, err := splunkalerts.GetTriggeredAlerts(pollCtx, client)
 		if err != nil {
 			logger.Errorf("Error calling GetTriggeredAlerts() while searchcing for new alerts: %v : %v", triggeredAlerts, err)
 		}
@@ -238,7 +270,7 @@ func FiringAlertsPoll(client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptn
 
 			if strings.HasSuffix(triggeredAlert.Name, keptnSuffix) {
 
-				triggeredInstances, err := splunkalerts.GetInstancesOfTriggeredAlert(client, triggeredAlert.Links.List)
+				triggeredInstances, err := splunkalerts.GetInstancesOfTriggeredAlert(pollCtx, client, triggeredAlert.Links.List)
 				if err != nil {
 					logger.Errorf("Error calling GetInstancesOfTriggeredAlert(): %v : %v", triggeredInstances, err)
 				}
@@ -259,10 +291,18 @@ func FiringAlertsPoll(client *splunk.SplunkClient, ddKeptn *keptnv2.Keptn, keptn
 			}
 
 		}
+		cancel()
+
 		// Condition only verified in case of a test
 		if ddKeptn != nil && isTestKeptn(ddKeptn.EventSender) {
 			return
 		}
-		time.Sleep(pollingFrequency * time.Second)
+
+		select {
+		case <-ctx.Done():
+			logger.Info("Stop polling for triggered alerts")
+			return
+		case <-time.After(pollingFrequency * time.Second):
+		}
 	}
 }
@@ -2,6 +2,7 @@ package alerts
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -11,6 +12,8 @@ import (
 	"testing"
 	"time"
 
+	splunkalerts "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/alerts"
+	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
 	splunktest "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/pkg/utils"
 	"github.com/keptn-sandbox/splunk-sli-provider/pkg/utils"
 
@@ -81,10 +84,10 @@ func TestFiringAlertsPoll(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to get Splunk Credentials: %v", err)
 	}
-	client := utils.ConnectToSplunk(*splunkCreds, true)
+	client := utils.ConnectToSplunk(*splunkCreds, &tls.Config{InsecureSkipVerify: true})
 
 	ddKeptn.UseLocalFileSystem = false
-	FiringAlertsPoll(client, ddKeptn, keptn.KeptnOpts{}, env)
+	FiringAlertsPoll(context.Background(), client, ddKeptn, keptn.KeptnOpts{}, env)
 
 	gotEvents := len(ddKeptn.EventSender.(*fake.EventSender).SentEvents)
 
@@ -111,6 +114,24 @@ func TestFiringAlertsPoll(t *testing.T) {
 
 }
 
+// Tests that the problem links to the results of the alert in splunk web when its url is set
+func TestProblemURL(t *testing.T) {
+	client := splunk.NewClientAuthenticatedByToken(&http.Client{}, "splunk", "8089", "apiToken", nil)
+	triggeredInstance := splunkalerts.EntryItem{
+		Links:   splunkalerts.Links{Job: "/servicesNS/admin/web/search/jobs/scheduler__admin__web__RMD5fee5950320bcf753_at_1689080400_135"},
+		Content: splunkalerts.Content{Sid: "scheduler__admin__web__RMD5fee5950320bcf753_at_1689080400_135"},
+	}
+
+	expected := "https://splunk:8000/app/web/search?sid=scheduler__admin__web__RMD5fee5950320bcf753_at_1689080400_135"
+	if link := problemURL(client, triggeredInstance, "https://splunk:8000"); link != expected {
+		t.Fatalf("Expected %s but got %s", expected, link)
+	}
+	expected = "https://splunk:8089/servicesNS/admin/web/search/jobs/scheduler__admin__web__RMD5fee5950320bcf753_at_1689080400_135/results"
+	if link := problemURL(client, triggeredInstance, ""); link != expected {
+		t.Fatalf("Expected %s but got %s", expected, link)
+	}
+}
+
 /**
  * loads from files the default responses we want the fake splunk server to send
  */
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -23,7 +24,7 @@ import (
 var createAlert = splunkalerts.CreateAlert
 
 // Handles configure monitoring event
-func HandleConfigureMonitoringTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevents.Event, data *keptnv2.ConfigureMonitoringTriggeredEventData, envConfig utils.EnvConfig, client *splunk.SplunkClient, pollingSystemHasBeenStarted bool) error {
+func HandleConfigureMonitoringTriggeredEvent(ctx context.Context, ddKeptn *keptnv2.Keptn, incomingEvent cloudevents.Event, data *keptnv2.ConfigureMonitoringTriggeredEventData, envConfig utils.EnvConfig, client *splunk.SplunkClient, pollingSystemHasBeenStarted bool) error {
 
 	if isNotForSplunk(data.ConfigureMonitoring.Type) {
 		logger.Infof("Event is not for splunk but for %s", data.ConfigureMonitoring.Type)
@@ -49,7 +50,7 @@ func HandleConfigureMonitoringTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEve
 	}
 
 	//Creating the alerts
-	setPollingSystem, err := CreateSplunkAlertsForEachStage(client, ddKeptn, *data, envConfig)
+	setPollingSystem, err := CreateSplunkAlertsForEachStage(ctx, client, ddKeptn, *data, envConfig)
 	if err != nil {
 		logger.Error(err.Error())
 		return err
@@ -59,7 +60,7 @@ func HandleConfigureMonitoringTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEve
 	case !pollingSystemHasBeenStarted && setPollingSystem:
 		go func() {
 			// Starts polling for triggered alerts if configure monitoring is successful
-			alerts.FiringAlertsPoll(client, ddKeptn, keptn.KeptnOpts{}, envConfig)
+			alerts.FiringAlertsPoll(ctx, client, ddKeptn, keptn.KeptnOpts{}, envConfig)
 		}()
 	case pollingSystemHasBeenStarted:
 		logger.Info("Polling system has already been started")
@@ -93,12 +94,15 @@ func HandleConfigureMonitoringTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEve
 }
 
 // Creates alerts for each stage defined in the shipyard file after removing potential ancient alerts of the service
-func CreateSplunkAlertsForEachStage(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData keptnv2.ConfigureMonitoringTriggeredEventData, envConfig utils.EnvConfig) (bool, error) {
+func CreateSplunkAlertsForEachStage(ctx context.Context, client *splunk.SplunkClient, k *keptnv2.Keptn, eventData keptnv2.ConfigureMonitoringTriggeredEventData, envConfig utils.EnvConfig) (bool, error) {
+
+	// the alerts of the project are created in its namespace
+	ctx = splunk.WithNamespace(ctx, utils.GetNamespace(envConfig, eventData.Project))
 
 	logger.Infof("Removing previous alerts set for the service %v in project %v", eventData.Service, eventData.Project)
 
 	//listing all alerts
-	alertsList, err := splunkalerts.ListAlertsNames(client)
+	alertsList, err := splunkalerts.ListAlertsNames(ctx, client)
 	if err != nil {
 		logger.Errorf("Error calling ListAlertsNames(): %v : %v", alertsList, err)
 		return false, fmt.Errorf("error calling ListAlertsNames(): %v : %w", alertsList, err)
@@ -108,7 +112,7 @@ func CreateSplunkAlertsForEachStage(client *splunk.SplunkClient, k *keptnv2.Kept
 	for _, alert := range alertsList.Item {
 		if strings.HasSuffix(alert.Name, KeptnSuffix) && strings.Contains(alert.Name, eventData.Project) && strings.Contains(alert.Name, eventData.Service) {
 			logger.Infof("Removing alert %v", alert.Name)
-			err := splunkalerts.RemoveAlert(client, alert.Name)
+			err := splunkalerts.RemoveAlert(ctx, client, alert.Name)
 			if err != nil {
 				logger.Errorf("Error calling RemoveAlert(): %v : %v", alertsList, err)
 				return false, fmt.Errorf("error calling RemoveAlert(): %v : %w", alertsList, err)
@@ -131,7 +135,7 @@ func CreateSplunkAlertsForEachStage(client *splunk.SplunkClient, k *keptnv2.Kept
 	//Creating the alerts for each stage of the shipyard file
 	for _, stage := range shipyard.Spec.Stages {
 		logger.Infof("Creating alerts for stage : %v", stage)
-		setPollingSystemTmp, err := CreateSplunkAlerts(client, k, eventData, stage, envConfig)
+		setPollingSystemTmp, err := CreateSplunkAlerts(ctx, client, k, eventData, stage, envConfig)
 		if err != nil {
 			return false, fmt.Errorf("error configuring splunk alerts: %w", err)
 		}
@@ -144,7 +148,7 @@ func CreateSplunkAlertsForEachStage(client *splunk.SplunkClient, k *keptnv2.Kept
 }
 
 // Creates the splunk alerts of a particular stage if slo.yaml and remediation.yaml files are defined
-func CreateSplunkAlerts(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData keptnv2.ConfigureMonitoringTriggeredEventData, stage keptnv2.Stage, envConfig utils.EnvConfig) (bool, error) {
+func CreateSplunkAlerts(ctx context.Context, client *splunk.SplunkClient, k *keptnv2.Keptn, eventData keptnv2.ConfigureMonitoringTriggeredEventData, stage keptnv2.Stage, envConfig utils.EnvConfig) (bool, error) {
 
 	//Trying to retrieve SLO file
 	slos, err := retrieveSLOs(k.ResourceHandler, eventData, stage.Name)
@@ -194,22 +198,45 @@ func CreateSplunkAlerts(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData
 		logger.Info("SLO: " + objective.DisplayName + ", " + objective.SLI)
 
 		//getting the splunk search query for the objective
-		query := projectCustomQueries[objective.SLI]
+		query := projectCustomQueries[objective.SLI].Query
 
+		// the alerts are searches of their own, they are not created from saved searches
+		if savedSearch := projectCustomQueries[objective.SLI].SavedSearch; savedSearch != "" {
+			logger.Infof("No alert created for SLI %s in project %s, it uses the saved search %s", objective.SLI, eventData.Project, savedSearch)
+			continue
+		}
 		if err != nil || query == "" {
 			logger.Error("No query defined for SLI " + objective.SLI + " in project " + eventData.Project)
 			continue
 		}
+		if err := projectCustomQueries[objective.SLI].Validate(); err != nil {
+			logger.Errorf("Invalid definition of SLI %s in project %s : %v", objective.SLI, eventData.Project, err)
+			continue
+		}
+		// the alerts are not scoped by custom filters, only get-sli events have some
+		query, err = utils.ApplyCustomFilters(query, nil, utils.GetAlertQueryVariables(eventData, stage.Name))
+		if err != nil {
+			logger.Errorf("Invalid query of SLI %s in project %s : %v", objective.SLI, eventData.Project, err)
+			continue
+		}
 		logger.Info("query= " + query)
 
 		//getting the name of the result field of the splunk sli search
-		resultField, err := getResultFieldName(query)
+		resultField := projectCustomQueries[objective.SLI].Field
+		if resultField == "" {
+			resultField, err = getResultFieldName(query)
+		}
 		if err != nil {
 			log.Println("Failed to get the result field name in order to create the alert condition for " + eventData.Project)
 			log.Println(err.Error())
 			return false, err
 		}
 
+		// the alert is triggered by the reduced value, like the indicator, and not by each result of the search
+		if reducer := projectCustomQueries[objective.SLI].Reducer; reducer != "" {
+			query = reduceInSearch(query, reducer, resultField)
+		}
+
 		//For each criteria of each pass criteria group of an objective (corresponding to an sli)
 		if objective.Pass != nil {
 			for _, criteriaGroup := range objective.Pass {
@@ -259,8 +286,15 @@ func CreateSplunkAlerts(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData
 						AlertSuppressPeriod: envConfig.AlertSuppressPeriod,
 						Actions:             envConfig.Actions,
 						WebhookUrl:          envConfig.WebhookUrl,
+						Sharing:             envConfig.AlertSharing,
+						ReadRoles:           envConfig.AlertReadRoles,
+						WriteRoles:          envConfig.AlertWriteRoles,
 					}
 					params.EarliestTime, params.LatestTime, params.SearchQuery = utils.RetrieveQueryTimeRange(params.EarliestTime, params.LatestTime, params.SearchQuery)
+					if err := errors.Join(utils.ValidateSplunkTime(params.EarliestTime), utils.ValidateSplunkTime(params.LatestTime)); err != nil {
+						logger.Errorf("Invalid time range of the search of SLI %s in project %s : %v", objective.SLI, eventData.Project, err)
+						continue
+					}
 
 					spAlert := splunkalerts.AlertRequest{
 						Params:  params,
@@ -268,7 +302,7 @@ func CreateSplunkAlerts(client *splunk.SplunkClient, k *keptnv2.Keptn, eventData
 					}
 
 					//Creates the alert in splunk
-					err = createAlert(client, &spAlert)
+					err = createAlert(ctx, client, &spAlert)
 					if err != nil {
 						logger.Errorf("Error calling CreateAlert(): %v : %v", spAlert.Params.SearchQuery, err)
 						return false, fmt.Errorf("error calling CreateAlert(): %v : %w", spAlert.Params.SearchQuery, err)
@@ -305,10 +339,10 @@ func retrieveSLOs(resourceHandler *api.ResourceHandler, eventData keptnv2.Config
 }
 
 // Returns the splunk searches defined in the sli.yaml file
-func getCustomQueries(k *keptnv2.Keptn, project string, stage string, service string) (map[string]string, error) {
+func getCustomQueries(k *keptnv2.Keptn, project string, stage string, service string) (map[string]utils.SLIDefinition, error) {
 	log.Println("Checking for custom SLI queries")
 
-	customQueries, err := k.GetSLIConfiguration(project, stage, service, sliFileUri)
+	customQueries, err := utils.GetSLIDefinitions(k.ResourceHandler, project, stage, service, sliFileUri)
 	if err != nil {
 		return nil, err
 	}
@@ -335,6 +369,15 @@ func getResultFieldName(searchQuery string) (string, error) {
 	return "", fmt.Errorf("no aggregation function found in the search query")
 }
 
+// Appends to the search the stats command collapsing its results like the reducer of the indicator
+func reduceInSearch(searchQuery string, reducer string, resultField string) string {
+	function := reducer
+	if strings.HasPrefix(reducer, "p") {
+		function = "perc" + strings.TrimPrefix(reducer, "p")
+	}
+	return fmt.Sprintf("%s | stats %s(%s) as %s", searchQuery, function, resultField, resultField)
+}
+
 // Appends "search", "result name" and criteria
 // e.g. search count > 0
 func buildAlertCondition(resultField string, criteria string) string {
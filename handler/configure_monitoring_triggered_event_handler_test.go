@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"context"
+	"crypto/tls"
 	"strings"
 	"testing"
 
@@ -62,7 +64,7 @@ func TestHandleConfigureMonitoringTriggeredEvent(t *testing.T) {
 
 	var alertCreated bool
 
-	createAlert = func(client *splunk.SplunkClient, spAlert *alerts.AlertRequest) error {
+	createAlert = func(ctx context.Context, client *splunk.SplunkClient, spAlert *alerts.AlertRequest) error {
 
 		if spAlert.Params.Name == data.Project+","+stage+","+data.Service+","+sli+","+criteria+","+KeptnSuffix &&
 			spAlert.Params.SearchQuery == `source="http:podtato-error" (index="keptn-splunk-dev") "[error]" | stats count` &&
@@ -79,9 +81,9 @@ func TestHandleConfigureMonitoringTriggeredEvent(t *testing.T) {
 		t.Fatalf("Failed to get splunk credentials: %s", err)
 		return
 	}
-	client := utils.ConnectToSplunk(*splunkCreds, true)
+	client := utils.ConnectToSplunk(*splunkCreds, &tls.Config{InsecureSkipVerify: true})
 	data.ConfigureMonitoring.Type = "splunk"
-	err = HandleConfigureMonitoringTriggeredEvent(ddKeptn, *incomingEvent, data, env, client, false)
+	err = HandleConfigureMonitoringTriggeredEvent(context.Background(), ddKeptn, *incomingEvent, data, env, client, false)
 
 	if err != nil {
 		t.Fatalf("Error: %v", err)
@@ -109,3 +111,17 @@ func TestHandleConfigureMonitoringTriggeredEvent(t *testing.T) {
 		t.Fatal("No alert has been created")
 	}
 }
+
+// Tests that the alert searches collapse their results like the reducer of the indicator
+func TestReduceInSearch(t *testing.T) {
+	query := reduceInSearch("index=main | timechart span=1m max(duration) as duration", "p95", "duration")
+	expected := "index=main | timechart span=1m max(duration) as duration | stats perc95(duration) as duration"
+	if query != expected {
+		t.Fatalf("Expected %s but got %s", expected, query)
+	}
+
+	query = reduceInSearch("index=main | timechart count", "sum", "count")
+	if !strings.HasSuffix(query, "| stats sum(count) as count") {
+		t.Fatalf("Expected the results to be summed but got %s", query)
+	}
+}
@@ -1,7 +1,12 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
 	splunkjobs "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/jobs"
@@ -16,8 +21,45 @@ const sliFileUri = "splunk/sli.yaml"
 const KeptnSuffix = "keptn"
 const serviceName = "splunk-sli-provider"
 
+// beginning of the message of the indicators skipped because their search returned no data
+const skippedMessage = "skipped"
+
+// prefixes of the labels of the get-sli.finished event describing the search job of each indicator
+// and linking to its search and results in splunk web
+const (
+	jobLabelPrefix     = "splunk.job."
+	searchLabelPrefix  = "splunk.search."
+	resultsLabelPrefix = "splunk.results."
+)
+
+// sliRequest is the search of an indicator, ready to be sent to splunk
+type sliRequest struct {
+	definition utils.SLIDefinition
+	spReq      splunkjobs.SearchRequest
+	// timezone of the relative times of the indicator, nil to let splunk evaluate them
+	timezone *time.Location
+}
+
+// sliBatch is a group of indicators whose searches run in a single job
+type sliBatch struct {
+	// indexes of the indicators in the requested ones
+	indexes  []int
+	requests []*sliRequest
+}
+
+// sliSearch is the search of an indicator sent to splunk
+type sliSearch struct {
+	params splunkjobs.SearchParams
+	// app in which the search runs
+	app string
+	// last status of the search job, nil if it could not be created
+	status *splunkjobs.JobStatus
+	// whether the indicator was skipped because its search returned no data
+	skipped bool
+}
+
 // HandleGetSliTriggeredEvent handles get-sli.triggered events if SLIProvider == splunk
-func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevents.Event, data *keptnv2.GetSLITriggeredEventData, client *splunk.SplunkClient) error {
+func HandleGetSliTriggeredEvent(ctx context.Context, ddKeptn *keptnv2.Keptn, incomingEvent cloudevents.Event, data *keptnv2.GetSLITriggeredEventData, envConfig utils.EnvConfig, client *splunk.SplunkClient) error {
 	var shkeptncontext string
 	_ = incomingEvent.Context.ExtensionAs("shkeptncontext", &shkeptncontext)
 	utils.ConfigureLogger(incomingEvent.Context.GetID(), shkeptncontext, "LOG_LEVEL")
@@ -31,6 +73,9 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 		return nil
 	}
 
+	// the searches run in the app of the project so that its macros, lookups and field extractions are available
+	ctx = splunk.WithNamespace(ctx, utils.GetNamespace(envConfig, data.Project))
+
 	// Step 2 - Send out a get-sli.started CloudEvent
 	// The get-sli.started cloud-event is new since Keptn 0.8.0 and is required to be send when the task is started
 	_, err := ddKeptn.SendTaskStartedEvent(data, serviceName)
@@ -52,10 +97,10 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 	// Step 5 - get SLI Config File
 	// Get SLI File from splunk subdirectory of the config repo - to add the file use:
 	//   keptn add-resource --project=PROJECT --stage=STAGE --service=SERVICE --resource=my-sli-config.yaml  --resourceUri=splunk/sli.yaml
-	sliConfig, err := ddKeptn.GetSLIConfiguration(data.Project, data.Stage, data.Service, sliFileUri)
+	sliConfig, err := utils.GetSLIDefinitions(ddKeptn.ResourceHandler, data.Project, data.Stage, data.Service, sliFileUri)
 	// FYI you do not need to "fail" if sli.yaml is missing, you can also assume smart defaults like we do
 	// in keptn-contrib/dynatrace-service and keptn-sandbox/splunk-sli-provider
-	logger.Infof("SLI Config: %s", sliConfig)
+	logger.Infof("SLI Config: %v", sliConfig)
 	if err != nil {
 		// failed to fetch sli config file
 		err := fmt.Errorf("failed to fetch SLI file %s from config repo: %w", sliFileUri, err)
@@ -63,9 +108,10 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 		// send a get-sli.finished event with status=error and result=failed back to Keptn
 
 		_, _ = ddKeptn.SendTaskFinishedEvent(&keptnv2.EventData{
-			Status: keptnv2.StatusErrored,
-			Result: keptnv2.ResultFailed,
-			Labels: labels,
+			Status:  keptnv2.StatusErrored,
+			Result:  keptnv2.ResultFailed,
+			Labels:  labels,
+			Message: err.Error(),
 		}, serviceName)
 
 		return err
@@ -74,19 +120,10 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 	// Indicators: this is the list of indicators as requested in the SLO.yaml
 	// SLIResult: this is the array that will receive the results
 	indicators := data.GetSLI.Indicators
-	sliResults := []*keptnv2.SLIResult{}
-
 	logger.Info("indicators:", indicators)
-	var sliResult *keptnv2.SLIResult
 
-	for _, indicatorName := range indicators {
-		sliResult, err = handleSpecificSLI(client, indicatorName, data, sliConfig)
-		if err != nil {
-			break
-		}
-
-		sliResults = append(sliResults, sliResult)
-	}
+	sliResults, sliSearches := getSLIResults(ctx, client, indicators, data, sliConfig, envConfig)
+	addSearchLabels(labels, indicators, sliSearches, envConfig.SplunkWebURL)
 
 	logger.Infof("SLI Results: %v", sliResults)
 	// Step 7 - Build get-sli.finished event data
@@ -102,12 +139,7 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 			End:             data.GetSLI.End,
 		},
 	}
-
-	if err != nil {
-		getSliFinishedEventData.EventData.Status = keptnv2.StatusErrored
-		getSliFinishedEventData.EventData.Result = keptnv2.ResultFailed
-		getSliFinishedEventData.EventData.Message = fmt.Sprintf("error from the %s while getting slis : %v", serviceName, err)
-	}
+	setFailedIndicators(&getSliFinishedEventData.EventData, sliResults, sliSearches, envConfig.SLIFailurePolicy)
 
 	logger.Infof("SLI finished event: %v", *getSliFinishedEventData)
 
@@ -122,43 +154,513 @@ func HandleGetSliTriggeredEvent(ddKeptn *keptnv2.Keptn, incomingEvent cloudevent
 	return nil
 }
 
+// Returns the results of the indicators in their order, those which could not be retrieved are reported as failed,
+// and their searches, nil for the indicators whose search could not be sent
+// the searches run concurrently, at most envConfig.MaxConcurrentSearches at a time, each batch of indicators counting as one search
+func getSLIResults(ctx context.Context, client *splunk.SplunkClient, indicators []string, data *keptnv2.GetSLITriggeredEventData, sliConfig map[string]utils.SLIDefinition, envConfig utils.EnvConfig) ([]*keptnv2.SLIResult, []*sliSearch) {
+	sliResults := make([]*keptnv2.SLIResult, len(indicators))
+	sliSearches := make([]*sliSearch, len(indicators))
+
+	maxConcurrentSearches := envConfig.MaxConcurrentSearches
+	if maxConcurrentSearches < 1 {
+		maxConcurrentSearches = 1
+	}
+	searches := make(chan struct{}, maxConcurrentSearches)
+	var wg sync.WaitGroup
+
+	start := time.Now()
+	// the relative times of all the indicators are evaluated at the same time, so that the same time range is normalized
+	// to the same epoch times and the searches of the indicators sharing it can be batched
+	requests := make([]*sliRequest, len(indicators))
+	for i, indicatorName := range indicators {
+		var err error
+		requests[i], err = prepareSLIRequest(indicatorName, data, sliConfig, envConfig, start)
+		if err != nil {
+			sliResults[i], sliSearches[i] = logSLIResult(indicatorName, 0, nil, nil, err)
+		}
+	}
+
+	batched := make(map[int]bool)
+	for _, batch := range getSLIBatches(requests, envConfig) {
+		for _, i := range batch.indexes {
+			batched[i] = true
+		}
+		wg.Add(1)
+		searches <- struct{}{}
+		go func(batch sliBatch) {
+			defer wg.Done()
+			defer func() { <-searches }()
+			getBatchSLIResults(ctx, client, indicators, batch, envConfig, sliResults, sliSearches)
+		}(batch)
+	}
+	for i, indicatorName := range indicators {
+		if batched[i] || requests[i] == nil {
+			continue
+		}
+		wg.Add(1)
+		searches <- struct{}{}
+		go func(i int, indicatorName string) {
+			defer wg.Done()
+			defer func() { <-searches }()
+			sliResults[i], sliSearches[i] = getSLIResult(ctx, client, indicatorName, requests[i], envConfig)
+		}(i, indicatorName)
+	}
+	wg.Wait()
+	logger.Infof("Got %d indicators in %v", len(indicators), time.Since(start))
+
+	return sliResults, sliSearches
+}
+
+// Returns the result of the prepared search of the indicator, failed if it could not be retrieved, and its search
+func getSLIResult(ctx context.Context, client *splunk.SplunkClient, indicatorName string, request *sliRequest, envConfig utils.EnvConfig) (*keptnv2.SLIResult, *sliSearch) {
+	start := time.Now()
+	sliResult, search, err := runSLIRequest(ctx, client, indicatorName, request, envConfig)
+	return logSLIResult(indicatorName, time.Since(start), sliResult, search, err)
+}
+
+// Logs the result of the indicator with the diagnostics of its search job, and returns it, failed if it could not be retrieved, with its search
+func logSLIResult(indicatorName string, duration time.Duration, sliResult *keptnv2.SLIResult, search *sliSearch, err error) (*keptnv2.SLIResult, *sliSearch) {
+	log := logger.WithFields(logger.Fields{"indicatorName": indicatorName, "duration": duration.String()})
+	if search != nil && search.status != nil {
+		log = log.WithFields(logger.Fields{
+			"sid":         search.status.Sid,
+			"scanCount":   search.status.ScanCount,
+			"resultCount": search.status.ResultCount,
+			"runDuration": search.status.RunDuration,
+			"messages":    joinMessages(search.status.Messages),
+		})
+	}
+
+	if err != nil {
+		log.Error(err)
+		return &keptnv2.SLIResult{
+			Metric:  indicatorName,
+			Success: false,
+			Message: err.Error(),
+		}, search
+	}
+	log.Infof("Got indicator %s in %v", indicatorName, duration)
+	return sliResult, search
+}
+
+// Returns the batches of indicators whose searches can run in a single job : those returning a single result, without saved search
+// nor completeness check, with the same app, time range and timeout, which are either explicitly batched, or share a base search if
+// envConfig.BatchSearches is set. The requests are those of the indicators, nil for the indicators whose search could not be prepared
+func getSLIBatches(requests []*sliRequest, envConfig utils.EnvConfig) []sliBatch {
+	batches := make(map[string]*sliBatch)
+	var keys []string
+	for i, request := range requests {
+		if request == nil || !isBatchable(request.definition, envConfig) {
+			continue
+		}
+
+		definition, params := request.definition, request.spReq.Params
+		// the explicitly batched indicators run together whatever their base search
+		group := ""
+		if definition.Batch == nil {
+			group = utils.BaseSearch(params.SearchQuery)
+			if group == "" {
+				continue
+			}
+		}
+		key := strings.Join([]string{definition.App, params.EarliestTime, params.LatestTime, request.spReq.Timeout.String(), group}, "\x00")
+		if _, ok := batches[key]; !ok {
+			batches[key] = &sliBatch{}
+			keys = append(keys, key)
+		}
+		batches[key].indexes = append(batches[key].indexes, i)
+		batches[key].requests = append(batches[key].requests, request)
+	}
+
+	var sliBatches []sliBatch
+	for _, key := range keys {
+		if len(batches[key].indexes) > 1 {
+			sliBatches = append(sliBatches, *batches[key])
+		}
+	}
+	return sliBatches
+}
+
+// return whether the search of the indicator can run in a batch
+func isBatchable(definition utils.SLIDefinition, envConfig utils.EnvConfig) bool {
+	if (definition.Batch != nil && !*definition.Batch) || (definition.Batch == nil && !envConfig.BatchSearches) {
+		return false
+	}
+	completeness := envConfig.CompletenessCheck
+	if definition.Completeness != "" {
+		completeness = definition.Completeness
+	}
+	return definition.Query != "" && definition.SavedSearch == "" && definition.Reducer == "" && (completeness == "" || completeness == utils.CompletenessNone)
+}
+
+// Gets the results of the batch of indicators from a single job, setting them and their searches at the indexes of the indicators
+// the indicators are searched separately if the job fails, and those without result in the job are searched again on their own
+// so that their no data policy never applies because of the batching
+func getBatchSLIResults(ctx context.Context, client *splunk.SplunkClient, indicators []string, batch sliBatch, envConfig utils.EnvConfig, sliResults []*keptnv2.SLIResult, sliSearches []*sliSearch) {
+	start := time.Now()
+	names := make([]string, len(batch.indexes))
+	batchSearches := make([]splunkjobs.BatchSearch, len(batch.requests))
+	spReq := batch.requests[0].spReq
+	for k, request := range batch.requests {
+		names[k] = indicators[batch.indexes[k]]
+		batchSearches[k] = splunkjobs.BatchSearch{Query: request.spReq.Params.SearchQuery, ResultField: request.spReq.ResultField}
+	}
+	batchCtx := ctx
+	if app := batch.requests[0].definition.App; app != "" {
+		batchCtx = splunk.WithNamespace(ctx, splunk.Namespace{Owner: splunk.NamespaceOf(ctx, client).Owner, App: app})
+	}
+
+	logger.WithFields(logger.Fields{"indicators": names}).Infof("Searching %d indicators in a single job", len(names))
+	logger.Infof("actual query sent to splunk: %v, from: %v, to: %v", splunkjobs.BatchQuery(batchSearches), spReq.Params.EarliestTime, spReq.Params.LatestTime)
+	metrics, errs, status, err := splunkjobs.GetMetricsFromNewBatchJob(batchCtx, client, &spReq, batchSearches)
+	if err != nil {
+		logger.WithFields(logger.Fields{"indicators": names}).Warnf("Could not get the batched indicators, searching them separately : %v", err)
+	}
+
+	for k, i := range batch.indexes {
+		if err != nil || errors.Is(errs[k], splunkjobs.ErrNoData) {
+			sliResults[i], sliSearches[i] = getSLIResult(ctx, client, indicators[i], batch.requests[k], envConfig)
+			continue
+		}
+		search := &sliSearch{params: batch.requests[k].spReq.Params, app: splunk.NamespaceOf(batchCtx, client).App, status: status}
+		sliResult, resultErr := newSLIResult(indicators[i], batch.requests[k], search, metrics[k], errs[k], "", envConfig)
+		sliResults[i], sliSearches[i] = logSLIResult(indicators[i], time.Since(start), sliResult, search, resultErr)
+	}
+}
+
+// Adds labels describing the search of each indicator : its job, e.g. splunk.job.error_count: sid=1689673231.191 scanCount=5000 resultCount=1 runDuration=1.25s,
+// and if the url of splunk web is set, links to the search over the exact time range and to the results of the job
+func addSearchLabels(labels map[string]string, indicators []string, sliSearches []*sliSearch, webURL string) {
+	for i, search := range sliSearches {
+		if search == nil {
+			continue
+		}
+		if webURL != "" {
+			labels[searchLabelPrefix+indicators[i]] = utils.SearchLink(webURL, search.app, search.params.SearchQuery, search.params.EarliestTime, search.params.LatestTime)
+		}
+
+		status := search.status
+		if status == nil {
+			continue
+		}
+		label := fmt.Sprintf("sid=%s scanCount=%d resultCount=%d runDuration=%vs", status.Sid, status.ScanCount, status.ResultCount, status.RunDuration)
+		if warnings := status.Warnings(); len(warnings) > 0 {
+			label += fmt.Sprintf(" warnings=%d", len(warnings))
+		}
+		labels[jobLabelPrefix+indicators[i]] = label
+		if webURL != "" {
+			labels[resultsLabelPrefix+indicators[i]] = utils.JobLink(webURL, search.app, status.Sid)
+		}
+	}
+}
+
+// return the messages of splunk as a single string
+func joinMessages(messages []splunk.Message) string {
+	texts := make([]string, 0, len(messages))
+	for _, message := range messages {
+		texts = append(texts, message.Type+" "+message.Text)
+	}
+	return strings.Join(texts, " ; ")
+}
+
+// Sets the status and result of the finished event when some indicators failed, according to the failure policy
+func setFailedIndicators(eventData *keptnv2.EventData, sliResults []*keptnv2.SLIResult, sliSearches []*sliSearch, failurePolicy string) {
+	var failures []string
+	for i, sliResult := range sliResults {
+		if !sliResult.Success && !isSkipped(sliSearches[i]) {
+			failures = append(failures, sliResult.Message)
+		}
+	}
+	if len(failures) == 0 {
+		return
+	}
+
+	eventData.Message = fmt.Sprintf("error from the %s while getting %d of %d slis : %s", serviceName, len(failures), len(sliResults), strings.Join(failures, " ; "))
+	// lighthouse evaluates the failed indicators against their objectives
+	if failurePolicy == utils.SLIFailurePolicyLighthouse {
+		return
+	}
+	eventData.Status = keptnv2.StatusErrored
+	eventData.Result = keptnv2.ResultFailed
+}
+
+// return whether the indicator was skipped because its search returned no data
+func isSkipped(search *sliSearch) bool {
+	return search != nil && search.skipped
+}
+
 // Executes the splunk search and return the metric value
-func handleSpecificSLI(client *splunk.SplunkClient, indicatorName string, data *keptnv2.GetSLITriggeredEventData, sliConfig map[string]string) (*keptnv2.SLIResult, error) {
+func handleSpecificSLI(ctx context.Context, client *splunk.SplunkClient, indicatorName string, data *keptnv2.GetSLITriggeredEventData, sliConfig map[string]utils.SLIDefinition, envConfig utils.EnvConfig) (*keptnv2.SLIResult, *sliSearch, error) {
+	request, err := prepareSLIRequest(indicatorName, data, sliConfig, envConfig, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	return runSLIRequest(ctx, client, indicatorName, request, envConfig)
+}
+
+// Returns the search of the indicator, with its custom filters, variables and time range applied
+// the relative times are evaluated at now if a timezone is set
+func prepareSLIRequest(indicatorName string, data *keptnv2.GetSLITriggeredEventData, sliConfig map[string]utils.SLIDefinition, envConfig utils.EnvConfig, now time.Time) (*sliRequest, error) {
+
+	definition, ok := sliConfig[indicatorName]
+	if !ok || (definition.Query == "" && definition.SavedSearch == "") {
+		return nil, fmt.Errorf("no query found for indicator %s", indicatorName)
+	}
+	if err := definition.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid definition of indicator %s in %s : %w", indicatorName, sliFileUri, err)
+	}
+
+	var query string
+	var err error
+	if definition.SavedSearch != "" {
+		// the search of a saved search is stored in splunk, it cannot be filtered nor have variables
+		if len(data.GetSLI.CustomFilters) > 0 {
+			logger.WithFields(logger.Fields{"indicatorName": indicatorName}).Warnf("The custom filters are not applied to the saved search %s", definition.SavedSearch)
+		}
+		query = utils.SavedSearchQuery(definition.SavedSearch)
+	} else {
+		query, err = utils.ApplyCustomFilters(definition.Query, data.GetSLI.CustomFilters, utils.GetSLIQueryVariables(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid query of indicator %s : %w", indicatorName, err)
+		}
+	}
 
-	query := sliConfig[indicatorName]
 	params := splunkjobs.SearchParams{
 		SearchQuery:  query,
 		EarliestTime: data.GetSLI.Start,
 		LatestTime:   data.GetSLI.End,
 	}
+	// the time range of the indicator overrides the one of the evaluation
+	if definition.Earliest != "" {
+		params.EarliestTime = definition.Earliest
+	}
+	if definition.Latest != "" {
+		params.LatestTime = definition.Latest
+	}
 
 	// take the time range from the sli file if it is set
 	params.EarliestTime, params.LatestTime, params.SearchQuery = utils.RetrieveQueryTimeRange(params.EarliestTime, params.LatestTime, params.SearchQuery)
-	logger.Infof("actual query sent to splunk: %v, from: %v, to: %v", params.SearchQuery, params.EarliestTime, params.LatestTime)
+	// the start and end of the evaluation are sent as epoch times so that the evaluation is reproducible
+	timezone, err := utils.GetTimezone(envConfig)
+	if err != nil {
+		return nil, err
+	}
+	params.EarliestTime, err = utils.NormalizeSplunkTime(params.EarliestTime, timezone, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid earliest time of indicator %s : %w", indicatorName, err)
+	}
+	params.LatestTime, err = utils.NormalizeSplunkTime(params.LatestTime, timezone, now)
+	if err != nil {
+		return nil, fmt.Errorf("invalid latest time of indicator %s : %w", indicatorName, err)
+	}
+	spReq := splunkjobs.SearchRequest{
+		Params:      params,
+		Headers:     map[string]string{},
+		Timeout:     envConfig.SearchTimeout,
+		ResultField: definition.Field,
+		Reducer:     definition.Reducer,
+	}
+	if definition.Timeout > 0 {
+		spReq.Timeout = definition.Timeout
+	}
+	return &sliRequest{definition: definition, spReq: spReq, timezone: timezone}, nil
+}
 
-	if query == "" {
-		return nil, fmt.Errorf("no query found for indicator %s", indicatorName)
+// Runs the search of the indicator in its own job and returns its result
+func runSLIRequest(ctx context.Context, client *splunk.SplunkClient, indicatorName string, request *sliRequest, envConfig utils.EnvConfig) (*keptnv2.SLIResult, *sliSearch, error) {
+	definition, spReq := request.definition, request.spReq
+	if definition.App != "" {
+		ctx = splunk.WithNamespace(ctx, splunk.Namespace{Owner: splunk.NamespaceOf(ctx, client).Owner, App: definition.App})
 	}
 
-	spReq := splunkjobs.SearchRequest{
-		Params:  params,
-		Headers: map[string]string{},
+	// the events of the end of the time range may not be indexed yet when the evaluation starts right after the test
+	completeness, maxIngestionLag := envConfig.CompletenessCheck, envConfig.MaxIngestionLag
+	if definition.Completeness != "" {
+		completeness = definition.Completeness
+	}
+	if definition.MaxIngestionLag > 0 {
+		maxIngestionLag = definition.MaxIngestionLag
 	}
+	var sliValue float64
+	var status *splunkjobs.JobStatus
+	var completenessMessage string
+	var err error
+	if definition.SavedSearch != "" {
+		logger.Infof("actual query sent to splunk: %v, from: %v, to: %v", spReq.Params.SearchQuery, spReq.Params.EarliestTime, spReq.Params.LatestTime)
+		// the saved search is dispatched over the time range, or its latest scheduled results are used if they are recent enough
+		sliValue, status, err = splunkjobs.GetMetricFromSavedSearch(ctx, client, definition.SavedSearch, &spReq, definition.MaxAge)
+	} else {
+		completenessMessage = checkCompleteness(ctx, client, indicatorName, &spReq, completeness, maxIngestionLag, request.timezone)
+		logger.Infof("actual query sent to splunk: %v, from: %v, to: %v", spReq.Params.SearchQuery, spReq.Params.EarliestTime, spReq.Params.LatestTime)
 
-	// get the metric we want
-	sliValue, err := splunkjobs.GetMetricFromNewJob(client, &spReq)
+		// get the metric we want
+		sliValue, status, err = splunkjobs.GetMetricAndStatusFromNewJob(ctx, client, &spReq)
+	}
+	search := &sliSearch{params: spReq.Params, app: splunk.NamespaceOf(ctx, client).App, status: status}
+	sliResult, err := newSLIResult(indicatorName, request, search, sliValue, err, completenessMessage, envConfig)
+	return sliResult, search, err
+}
+
+// Returns the result of the indicator from the metric of its search and the last status of the search job,
+// applying its no data policy, marking the search as skipped if needed, and reporting the warnings of splunk with the given message
+func newSLIResult(indicatorName string, request *sliRequest, search *sliSearch, sliValue float64, err error, message string, envConfig utils.EnvConfig) (*keptnv2.SLIResult, error) {
+	definition, status := request.definition, search.status
+	if errors.Is(err, splunkjobs.ErrNoData) {
+		switch definition.NoDataPolicy() {
+		case utils.NoDataValue:
+			logger.WithFields(logger.Fields{"indicatorName": indicatorName}).Infof("No data for the indicator, using its default value %v", definition.DefaultValue())
+			sliValue, err = definition.DefaultValue(), nil
+		case utils.NoDataSkip:
+			logger.WithFields(logger.Fields{"indicatorName": indicatorName}).Infof("No data for the indicator, skipping it : %v", err)
+			search.skipped = true
+			return &keptnv2.SLIResult{
+				Metric:  indicatorName,
+				Success: false,
+				Message: fmt.Sprintf("%s : no data for indicator %s", skippedMessage, indicatorName),
+			}, nil
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("error getting value for the query: %v : %w", spReq.Params.SearchQuery, err)
+		return nil, fmt.Errorf("%s. Error getting value for the query: %v : %w", describeSplunkError(indicatorName, err), request.spReq.Params.SearchQuery, err)
 	}
 
-	logger.Infof("response from the metrics api: %v", sliValue)
+	logger.Infof("response from the metrics api: %v %s", sliValue, definition.Unit)
 
 	sliResult := &keptnv2.SLIResult{
 		Metric:  indicatorName,
 		Value:   sliValue,
 		Success: true,
+		Message: message,
+	}
+	// the warnings of splunk, like results truncated or a search finalized before its completion, may make the value wrong
+	if warnings := status.Warnings(); len(warnings) > 0 {
+		warningsMessage := "splunk warnings : " + joinMessages(warnings)
+		if sliResult.Message != "" {
+			warningsMessage = sliResult.Message + " ; " + warningsMessage
+		}
+		sliResult.Message = warningsMessage
+
+		failOnWarnings := envConfig.FailOnJobWarnings
+		if definition.FailOnWarnings != nil {
+			failOnWarnings = *definition.FailOnWarnings
+		}
+		sliResult.Success = !failOnWarnings
 	}
 	logger.WithFields(logger.Fields{"indicatorName": indicatorName}).Infof("SLI result from the metrics api: %v", sliResult)
 
 	return sliResult, nil
 }
+
+// Checks whether the events of the time range of the search are indexed, from the ingestion lag measured on its events
+// depending on the completeness check, waits for their ingestion or shifts the time range back, at most by the maximum ingestion lag
+// returns the message of the result of the indicator, empty if the data is complete
+func checkCompleteness(ctx context.Context, client *splunk.SplunkClient, indicatorName string, spReq *splunkjobs.SearchRequest, completeness string, maxIngestionLag time.Duration, timezone *time.Location) string {
+	if completeness == "" || completeness == utils.CompletenessNone {
+		return ""
+	}
+	log := logger.WithFields(logger.Fields{"indicatorName": indicatorName})
+
+	query, err := utils.CompletenessQuery(spReq.Params.SearchQuery)
+	if err != nil {
+		log.Warn(err)
+		return "the completeness of the data could not be checked : " + err.Error()
+	}
+	// the times were normalized, only the relative ones still have to be evaluated
+	if timezone == nil {
+		timezone = time.UTC
+	}
+	now := time.Now().In(timezone)
+	earliest, err := utils.ParseSplunkTime(spReq.Params.EarliestTime, now)
+	if err != nil {
+		return "the completeness of the data could not be checked : " + err.Error()
+	}
+	latest, err := utils.ParseSplunkTime(spReq.Params.LatestTime, now)
+	if err != nil {
+		return "the completeness of the data could not be checked : " + err.Error()
+	}
+
+	lagReq := splunkjobs.SearchRequest{
+		Params: splunkjobs.SearchParams{
+			SearchQuery:  query,
+			EarliestTime: spReq.Params.EarliestTime,
+			LatestTime:   spReq.Params.LatestTime,
+		},
+		Headers:     map[string]string{},
+		Timeout:     spReq.Timeout,
+		ResultField: utils.IngestionLagField,
+	}
+	deadline := time.Now().Add(maxIngestionLag)
+	for {
+		lag, err := splunkjobs.GetMetricFromNewJob(ctx, client, &lagReq)
+		if errors.Is(err, splunkjobs.ErrNoData) {
+			log.Warn("No event of the time range is indexed")
+			return "the data may be incomplete : no event of the time range is indexed yet"
+		}
+		if err != nil {
+			log.Warnf("Could not check the completeness of the data : %v", err)
+			return "the completeness of the data could not be checked : " + err.Error()
+		}
+
+		// the events of the end of the time range are indexed once the ingestion lag elapsed
+		missing := time.Until(latest.Add(time.Duration(lag * float64(time.Second))))
+		if missing <= 0 {
+			return ""
+		}
+		log.Infof("The events of the last %v of the time range may not be indexed yet, ingestion lag of %vs", missing, lag)
+
+		switch completeness {
+		case utils.CompletenessWait:
+			remaining := time.Until(deadline)
+			if remaining > 0 {
+				if missing > remaining {
+					missing = remaining
+				}
+				select {
+				case <-ctx.Done():
+					return "the data may be incomplete : " + ctx.Err().Error()
+				case <-time.After(missing):
+				}
+				continue
+			}
+			return fmt.Sprintf("the data may be incomplete : the events of the last %v of the time range may not be indexed yet after waiting %v", missing.Round(time.Second), maxIngestionLag)
+		case utils.CompletenessShift:
+			shift := missing
+			message := fmt.Sprintf("the time range was shifted back by %v to compensate the ingestion lag", shift.Round(time.Second))
+			if shift > maxIngestionLag {
+				shift = maxIngestionLag
+				message = fmt.Sprintf("the data may be incomplete : the time range was shifted back by %v but the events of the last %v may not be indexed yet", shift, missing.Round(time.Second))
+			}
+			spReq.Params.EarliestTime = utils.FormatEpochTime(earliest.Add(-shift))
+			spReq.Params.LatestTime = utils.FormatEpochTime(latest.Add(-shift))
+			log.Infof("Time range shifted back by %v, from %s to %s", shift, spReq.Params.EarliestTime, spReq.Params.LatestTime)
+			return message
+		}
+		return fmt.Sprintf("the data may be incomplete : the events of the last %v of the time range may not be indexed yet", missing.Round(time.Second))
+	}
+}
+
+// return an explanation of the error returned by splunk for the indicator
+func describeSplunkError(indicatorName string, err error) string {
+	var jobErr *splunkjobs.JobError
+	if errors.As(err, &jobErr) {
+		if jobErr.Cancelled {
+			return fmt.Sprintf("the search of indicator %s did not complete in time", indicatorName)
+		}
+		return fmt.Sprintf("the search of indicator %s failed", indicatorName)
+	}
+	if errors.Is(err, splunkjobs.ErrNoData) {
+		return fmt.Sprintf("no data for indicator %s", indicatorName)
+	}
+
+	switch splunk.ErrorKindOf(err) {
+	case splunk.ErrorKindAuthentication:
+		return fmt.Sprintf("splunk rejected the credentials used to get indicator %s", indicatorName)
+	case splunk.ErrorKindInvalidSearch:
+		return fmt.Sprintf("the query of indicator %s is not a valid splunk search", indicatorName)
+	case splunk.ErrorKindQuota:
+		return fmt.Sprintf("splunk search quota exceeded while getting indicator %s", indicatorName)
+	case splunk.ErrorKindNotFound:
+		return fmt.Sprintf("splunk object not found while getting indicator %s", indicatorName)
+	}
+	return fmt.Sprintf("could not get indicator %s", indicatorName)
+}
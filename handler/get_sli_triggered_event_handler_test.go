@@ -2,17 +2,24 @@ package handler
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
+	splunkjobs "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/jobs"
 	splunktest "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/pkg/utils"
 	"github.com/keptn-sandbox/splunk-sli-provider/pkg/utils"
 
@@ -84,8 +91,8 @@ func initializeTestObjects(eventFileName string, resourceServiceUrl string) (*ke
 func TestHandleSpecificSli(t *testing.T) {
 	indicatorName := "test"
 	data := &keptnv2.GetSLITriggeredEventData{}
-	sliConfig := make(map[string]string, 1)
-	sliConfig[indicatorName] = "test"
+	sliConfig := make(map[string]utils.SLIDefinition, 1)
+	sliConfig[indicatorName] = utils.SLIDefinition{Query: "test"}
 
 	//Building a mock splunk server returning default responses when getting  get and post requests
 
@@ -106,9 +113,9 @@ func TestHandleSpecificSli(t *testing.T) {
 		splunkCreds.Host,
 		splunkCreds.Port,
 		splunkCreds.Token,
-		true,
+		&tls.Config{InsecureSkipVerify: true},
 	)
-	sliResult, errored := handleSpecificSLI(client, indicatorName, data, sliConfig)
+	sliResult, _, errored := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{})
 
 	if errored != nil {
 		t.Fatal(errored.Error())
@@ -119,6 +126,725 @@ func TestHandleSpecificSli(t *testing.T) {
 	}
 }
 
+// Tests that the errors returned by splunk are explained in the error of handleSpecificSLI
+func TestHandleSpecificSliSplunkError(t *testing.T) {
+	indicatorName := "test"
+	data := &keptnv2.GetSLITriggeredEventData{}
+	sliConfig := map[string]utils.SLIDefinition{indicatorName: {Query: "index=main | stat count"}}
+
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"messages":[{"type":"FATAL","text":"Unknown search command 'stat'."}]}`))
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	_, _, err := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{})
+
+	if err == nil || !strings.Contains(err.Error(), "the query of indicator test is not a valid splunk search") || !strings.Contains(err.Error(), "Unknown search command 'stat'.") {
+		t.Fatalf("Expected an explanation of the splunk error but got %v", err)
+	}
+}
+
+// Tests that the settings of the indicator are applied to its search
+func TestHandleSpecificSliSettings(t *testing.T) {
+	indicatorName := "test"
+	data := &keptnv2.GetSLITriggeredEventData{}
+	data.GetSLI.Start = "2023-07-18T10:00:00.000Z"
+	data.GetSLI.End = "2023-07-18T10:05:00.000Z"
+	defaultValue := 42.0
+	sliConfig := map[string]utils.SLIDefinition{indicatorName: {
+		Query:    "index=main | stats count",
+		Default:  &defaultValue,
+		Earliest: "-1h",
+		App:      "web",
+	}}
+
+	var path string
+	var params url.Values
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			path = r.URL.Path
+			body, _ := io.ReadAll(r.Body)
+			params, _ = url.ParseQuery(string(body))
+			_, _ = w.Write([]byte(`{"sid":"10"}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[]}`))
+		default:
+			_, _ = w.Write([]byte(splunktest.DoneJobStatus))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	sliResult, _, err := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{})
+
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if sliResult.Value != defaultValue {
+		t.Fatalf("Expected the default value when there is no data but got %v", sliResult.Value)
+	}
+	if path != "/servicesNS/nobody/web/search/v2/jobs/" {
+		t.Fatalf("Expected the search to run in the app of the indicator but got %s", path)
+	}
+	// the end of the evaluation is sent as an epoch time
+	if params.Get("earliest_time") != "-1h" || params.Get("latest_time") != "1689674700" {
+		t.Fatalf("Expected the earliest time of the indicator but got %v", params)
+	}
+
+	sliConfig[indicatorName] = utils.SLIDefinition{Query: "index=main | stats count", Reducer: "median"}
+	_, _, err = handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{})
+	if err == nil || !strings.Contains(err.Error(), "invalid definition of indicator test") {
+		t.Fatalf("Expected a validation error but got %v", err)
+	}
+}
+
+// Tests that the no data policy of the indicator applies when the search returns no result or a null value
+func TestHandleSpecificSliNoData(t *testing.T) {
+	data := &keptnv2.GetSLITriggeredEventData{}
+	defaultValue := 5.0
+	sliConfig := map[string]utils.SLIDefinition{
+		"no_result":          {Query: "search index=main error | stats count by host"},
+		"null_value":         {Query: "search index=main | stats avg(duration) as avg"},
+		"no_result_zero":     {Query: "search index=main error | stats count by host", NoData: utils.NoDataValue},
+		"no_result_default":  {Query: "search index=main error | stats count by host", Default: &defaultValue},
+		"null_value_skipped": {Query: "search index=main | stats avg(duration) as avg", NoData: utils.NoDataSkip},
+		"no_result_failing":  {Query: "search index=main error | stats count by host", Default: &defaultValue, NoData: utils.NoDataError},
+	}
+
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			params, _ := url.ParseQuery(string(body))
+			if strings.Contains(params.Get("search"), "avg") {
+				_, _ = w.Write([]byte(`{"sid":"avg"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"sid":"count"}`))
+		case strings.HasSuffix(r.URL.Path, "/avg/results"):
+			_, _ = w.Write([]byte(`{"results":[{"avg":"N/A"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[]}`))
+		default:
+			_, _ = w.Write([]byte(splunktest.DoneJobStatus))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	for _, indicatorName := range []string{"no_result", "null_value", "no_result_failing"} {
+		_, _, err := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{})
+		if err == nil || !strings.Contains(err.Error(), "no data for indicator "+indicatorName) {
+			t.Fatalf("Expected indicator %s to fail without data but got %v", indicatorName, err)
+		}
+	}
+	for indicatorName, expected := range map[string]float64{"no_result_zero": 0, "no_result_default": defaultValue} {
+		sliResult, _, err := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{})
+		if err != nil || !sliResult.Success || sliResult.Value != expected {
+			t.Fatalf("Expected indicator %s to be %v without data but got %+v, %v", indicatorName, expected, sliResult, err)
+		}
+	}
+	sliResult, search, err := handleSpecificSLI(context.Background(), client, "null_value_skipped", data, sliConfig, utils.EnvConfig{})
+	if err != nil || sliResult.Success || !isSkipped(search) {
+		t.Fatalf("Expected indicator null_value_skipped to be skipped but got %+v, %v", sliResult, err)
+	}
+}
+
+// Tests that the values of the custom filters are sent as is, even when they look like variables
+func TestHandleSpecificSliCustomFiltersWithVariables(t *testing.T) {
+	indicatorName := "test"
+	data := &keptnv2.GetSLITriggeredEventData{}
+	data.Project = "sockshop"
+	data.GetSLI.CustomFilters = []*keptnv2.SLIFilter{{Key: "message", Value: "cost of $PROJECT"}, {Key: "tag", Value: "$LABEL.x"}}
+	sliConfig := map[string]utils.SLIDefinition{indicatorName: {Query: "index=main project=$PROJECT | stats count"}}
+
+	var sentSearch string
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			params, _ := url.ParseQuery(string(body))
+			sentSearch = params.Get("search")
+			_, _ = w.Write([]byte(`{"sid":"1689673231.191"}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"12"}]}`))
+		default:
+			_, _ = w.Write([]byte(splunktest.DoneJobStatus))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	sliResult, _, err := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{})
+	if err != nil || !sliResult.Success || sliResult.Value != 12 {
+		t.Fatalf("Expected the indicator to succeed but got %+v, %v", sliResult, err)
+	}
+	expected := `search index=main project="sockshop" message="cost of $PROJECT" tag="$LABEL.x" | stats count`
+	if sentSearch != expected {
+		t.Fatalf("Expected the search %s but got %s", expected, sentSearch)
+	}
+}
+
+// Tests that the labels link to the searches of the indicators in splunk web
+func TestAddSearchLabels(t *testing.T) {
+	sliSearches := []*sliSearch{
+		{
+			params: splunkjobs.SearchParams{SearchQuery: "search index=main error | stats count", EarliestTime: "1689674400", LatestTime: "1689674700"},
+			app:    "web",
+			status: &splunkjobs.JobStatus{Sid: "1689673231.191", ScanCount: 10, ResultCount: 1, RunDuration: 0.5},
+		},
+		// the job could not be created
+		{params: splunkjobs.SearchParams{SearchQuery: "index=main | stats count"}},
+		nil,
+	}
+	labels := map[string]string{}
+	addSearchLabels(labels, []string{"error_count", "request_count", "undefined"}, sliSearches, "https://splunk:8000")
+
+	expected := map[string]string{
+		"splunk.job.error_count":      "sid=1689673231.191 scanCount=10 resultCount=1 runDuration=0.5s",
+		"splunk.search.error_count":   "https://splunk:8000/app/web/search?earliest=1689674400&latest=1689674700&q=search+index%3Dmain+error+%7C+stats+count",
+		"splunk.results.error_count":  "https://splunk:8000/app/web/search?sid=1689673231.191",
+		"splunk.search.request_count": "https://splunk:8000/app/search/search?q=index%3Dmain+%7C+stats+count",
+	}
+	if len(labels) != len(expected) {
+		t.Fatalf("Expected the labels %v but got %v", expected, labels)
+	}
+	for key, value := range expected {
+		if labels[key] != value {
+			t.Fatalf("Expected label %s to be %s but got %s", key, value, labels[key])
+		}
+	}
+}
+
+// Tests that the warnings of the search job are reported in the result of the indicator, which fails if required
+func TestHandleSpecificSliJobWarnings(t *testing.T) {
+	indicatorName := "test"
+	data := &keptnv2.GetSLITriggeredEventData{}
+	sliConfig := map[string]utils.SLIDefinition{indicatorName: {Query: "search index=main | stats count"}}
+
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"sid":"1689673231.191"}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"12"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"entry":[{"content":{"dispatchState":"DONE","isDone":true,"scanCount":5000,"resultCount":1,"runDuration":1.25,
+				"messages":[{"type":"WARN","text":"Search results might be incomplete."}]}}]}`))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	sliResult, search, err := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{})
+	if err != nil || !sliResult.Success || sliResult.Value != 12 || sliResult.Message != "splunk warnings : WARN Search results might be incomplete." {
+		t.Fatalf("Expected the warnings in the message of the indicator but got %+v, %v", sliResult, err)
+	}
+	labels := map[string]string{}
+	addSearchLabels(labels, []string{indicatorName}, []*sliSearch{search}, "")
+	if len(labels) != 1 || labels["splunk.job.test"] != "sid=1689673231.191 scanCount=5000 resultCount=1 runDuration=1.25s warnings=1" {
+		t.Fatalf("Expected a label describing the job but got %v", labels)
+	}
+
+	sliResult, _, err = handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{FailOnJobWarnings: true})
+	if err != nil || sliResult.Success {
+		t.Fatalf("Expected the indicator to fail because of the warnings but got %+v, %v", sliResult, err)
+	}
+
+	failOnWarnings := false
+	sliConfig[indicatorName] = utils.SLIDefinition{Query: "search index=main | stats count", FailOnWarnings: &failOnWarnings}
+	sliResult, _, err = handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{FailOnJobWarnings: true})
+	if err != nil || !sliResult.Success {
+		t.Fatalf("Expected the setting of the indicator to take precedence but got %+v, %v", sliResult, err)
+	}
+}
+
+// Tests that the completeness check waits for the ingestion of the events, shifts the time range or warns
+func TestHandleSpecificSliCompleteness(t *testing.T) {
+	indicatorName := "test"
+	end := time.Now()
+	data := &keptnv2.GetSLITriggeredEventData{}
+	data.GetSLI.Start = end.Add(-10 * time.Minute).Format(time.RFC3339)
+	data.GetSLI.End = end.Format(time.RFC3339)
+	sliConfig := map[string]utils.SLIDefinition{indicatorName: {Query: "search index=main | stats count"}}
+
+	var mutex sync.Mutex
+	var lag string
+	var lagSearches int
+	var params url.Values
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch {
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			searchParams, _ := url.ParseQuery(string(body))
+			if strings.Contains(searchParams.Get("search"), "_indextime") {
+				lagSearches++
+				_, _ = w.Write([]byte(`{"sid":"lag"}`))
+				return
+			}
+			params = searchParams
+			_, _ = w.Write([]byte(`{"sid":"sli"}`))
+		case strings.HasSuffix(r.URL.Path, "/lag/results"):
+			_, _ = fmt.Fprintf(w, `{"results":[{"ingestionLag":"%s"}]}`, lag)
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"12"}]}`))
+		default:
+			_, _ = w.Write([]byte(splunktest.DoneJobStatus))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	getSLI := func(lagSeconds int, env utils.EnvConfig) *keptnv2.SLIResult {
+		mutex.Lock()
+		lag = fmt.Sprint(lagSeconds)
+		lagSearches = 0
+		mutex.Unlock()
+		sliResult, _, err := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, env)
+		if err != nil || sliResult.Value != 12 {
+			t.Fatalf("Expected the value of the indicator but got %v, %v", sliResult, err)
+		}
+		return sliResult
+	}
+
+	sliResult := getSLI(60, utils.EnvConfig{CompletenessCheck: utils.CompletenessWarn, MaxIngestionLag: time.Minute})
+	if !strings.Contains(sliResult.Message, "the data may be incomplete") || lagSearches != 1 || params.Get("latest_time") != fmt.Sprint(end.Unix()) {
+		t.Fatalf("Expected a warning without changing the time range but got %+v, %v", sliResult, params)
+	}
+
+	sliResult = getSLI(60, utils.EnvConfig{CompletenessCheck: utils.CompletenessShift, MaxIngestionLag: 5 * time.Minute})
+	if !strings.Contains(sliResult.Message, "shifted back") || strings.Contains(sliResult.Message, "incomplete") {
+		t.Fatalf("Expected the time range to be shifted but got %+v", sliResult)
+	}
+	// the time range is shifted back by the ingestion lag
+	latest, _ := strconv.ParseFloat(params.Get("latest_time"), 64)
+	if shift := float64(end.Unix()) - latest; shift < 55 || shift > 60 {
+		t.Fatalf("Expected the time range to be shifted back by about a minute but got %v", params)
+	}
+
+	sliResult = getSLI(600, utils.EnvConfig{CompletenessCheck: utils.CompletenessShift, MaxIngestionLag: 2 * time.Minute})
+	earliest, _ := strconv.ParseFloat(params.Get("earliest_time"), 64)
+	if !strings.Contains(sliResult.Message, "the data may be incomplete") || float64(end.Unix())-600-earliest != 120 {
+		t.Fatalf("Expected the shift to be limited to the maximum ingestion lag but got %+v, %v", sliResult, params)
+	}
+
+	// the events of the end of the time range are indexed after about a second
+	sliConfig[indicatorName] = utils.SLIDefinition{Query: "search index=main | stats count", Completeness: utils.CompletenessWait, MaxIngestionLag: 5 * time.Second}
+	sliResult = getSLI(int(time.Until(end.Add(time.Second)).Seconds()+1), utils.EnvConfig{})
+	if sliResult.Message != "" || lagSearches != 2 || params.Get("latest_time") != fmt.Sprint(end.Unix()) {
+		t.Fatalf("Expected to wait for the ingestion of the events but got %+v after %d checks", sliResult, lagSearches)
+	}
+}
+
+// Tests that the indicators defined by a saved search dispatch it over the time range of the evaluation
+func TestHandleSpecificSliSavedSearch(t *testing.T) {
+	indicatorName := "checkout_errors"
+	data := &keptnv2.GetSLITriggeredEventData{}
+	data.GetSLI.Start = "2021-01-15T15:04:45.000Z"
+	data.GetSLI.End = "2021-01-15T15:09:45.000Z"
+	data.GetSLI.CustomFilters = []*keptnv2.SLIFilter{{Key: "host", Value: "web-1"}}
+	sliConfig := map[string]utils.SLIDefinition{indicatorName: {SavedSearch: "Checkout errors"}}
+
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/services/saved/searches/Checkout errors/dispatch":
+			_ = r.ParseForm()
+			if r.PostForm.Get("dispatch.earliest_time") != "1610723085" || r.PostForm.Get("dispatch.latest_time") != "1610723385" {
+				t.Errorf("Expected the saved search to be dispatched over the evaluation but got %v", r.PostForm)
+			}
+			_, _ = w.Write([]byte(`{"sid":"admin__admin__search__1689673231.191"}`))
+		case r.Method == http.MethodPost:
+			t.Errorf("Expected only the dispatch of the saved search but got %s", r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"7"}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"entry":[{"content":{"dispatchState":"DONE","isDone":true}}]}`))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	// the completeness of the events of a saved search is not checked, its search is not known
+	sliResult, search, err := handleSpecificSLI(context.Background(), client, indicatorName, data, sliConfig, utils.EnvConfig{CompletenessCheck: utils.CompletenessWait})
+	if err != nil || !sliResult.Success || sliResult.Value != 7 || sliResult.Message != "" {
+		t.Fatalf("Expected the value of the saved search but got %+v, %v", sliResult, err)
+	}
+	if search.params.SearchQuery != `| savedsearch "Checkout errors"` || search.status.Sid != "admin__admin__search__1689673231.191" {
+		t.Fatalf("Expected the search of the saved search but got %+v", search)
+	}
+}
+
+// Tests that an indicator which could not be retrieved does not prevent getting the others
+func TestGetSLIResultsPartialFailure(t *testing.T) {
+	data := &keptnv2.GetSLITriggeredEventData{}
+	sliConfig := map[string]utils.SLIDefinition{
+		"first":  {Query: "index=main | stats count"},
+		"second": {Query: "index=main | stats count"},
+	}
+
+	splunkServer := utils.BuildMockSplunkServer(defaultSplunkTestResult)
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	sliResults, _ := getSLIResults(context.Background(), client, []string{"first", "undefined", "second"}, data, sliConfig, utils.EnvConfig{})
+
+	if len(sliResults) != 3 {
+		t.Fatalf("Expected a result for each indicator but got %v", sliResults)
+	}
+	for _, i := range []int{0, 2} {
+		if !sliResults[i].Success || sliResults[i].Value != float64(defaultSplunkTestResult) {
+			t.Fatalf("Expected indicator %s to succeed but got %+v", sliResults[i].Metric, sliResults[i])
+		}
+	}
+	if sliResults[1].Metric != "undefined" || sliResults[1].Success || !strings.Contains(sliResults[1].Message, "no query found for indicator undefined") {
+		t.Fatalf("Expected indicator undefined to fail with a message but got %+v", sliResults[1])
+	}
+}
+
+// Tests that the searches run concurrently within the limit and that the results keep the order of the indicators
+func TestGetSLIResultsConcurrently(t *testing.T) {
+	data := &keptnv2.GetSLITriggeredEventData{}
+	sliConfig := map[string]utils.SLIDefinition{}
+	indicators := []string{}
+	for i := 0; i < 10; i++ {
+		indicatorName := fmt.Sprintf("indicator%d", i)
+		indicators = append(indicators, indicatorName)
+		sliConfig[indicatorName] = utils.SLIDefinition{Query: fmt.Sprintf("index=main | stats count | eval count=%d", i)}
+	}
+
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+	values := map[string]string{}
+	// closed once the limit of concurrent searches is reached, the first searches wait for it to complete
+	// so that they can only complete if they run in parallel
+	allRunning := make(chan struct{})
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			params, _ := url.ParseQuery(string(body))
+			search := params.Get("search")
+
+			mutex.Lock()
+			sid := fmt.Sprint(len(values))
+			values[sid] = search[strings.LastIndex(search, "=")+1:]
+			running++
+			if running > maxRunning {
+				maxRunning = running
+				if maxRunning == 3 {
+					close(allRunning)
+				}
+			}
+			mutex.Unlock()
+			_, _ = fmt.Fprintf(w, `{"sid":"%s"}`, sid)
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			// a sequential implementation never reaches the barrier, the timeout only keeps the test from hanging
+			select {
+			case <-allRunning:
+			case <-time.After(time.Second):
+			}
+			segments := strings.Split(r.URL.Path, "/")
+
+			mutex.Lock()
+			running--
+			value := values[segments[len(segments)-2]]
+			mutex.Unlock()
+			_, _ = fmt.Fprintf(w, `{"results":[{"count":"%s"}]}`, value)
+		default:
+			_, _ = w.Write([]byte(splunktest.DoneJobStatus))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	sliResults, _ := getSLIResults(context.Background(), client, indicators, data, sliConfig, utils.EnvConfig{MaxConcurrentSearches: 3})
+
+	for i, sliResult := range sliResults {
+		if sliResult.Metric != indicators[i] || !sliResult.Success || sliResult.Value != float64(i) {
+			t.Fatalf("Expected the result of %s at position %d but got %+v", indicators[i], i, sliResult)
+		}
+	}
+	if maxRunning != 3 {
+		t.Fatalf("Expected 3 searches at the same time but got %d", maxRunning)
+	}
+}
+
+// Tests that the indicators sharing a base search run in a single job, and are searched separately when it cannot be split
+func TestGetSLIResultsBatched(t *testing.T) {
+	data := &keptnv2.GetSLITriggeredEventData{}
+	indicators := []string{"errors", "latency", "web", "hosts"}
+	sliConfig := map[string]utils.SLIDefinition{
+		"errors":  {Query: "index=main error | stats count", Field: "count"},
+		"latency": {Query: "index=main error | stats avg(duration) as avg, count", Field: "avg"},
+		"web":     {Query: "index=web | stats count", Field: "count"},
+		"hosts":   {Query: "index=main error | stats dc(host)", Field: "count"},
+	}
+
+	var mutex sync.Mutex
+	var failBatch bool
+	var jobs []string
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			params, _ := url.ParseQuery(string(body))
+			search := params.Get("search")
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			jobs = append(jobs, search)
+			if !strings.Contains(search, "appendcols") {
+				_, _ = fmt.Fprintf(w, `{"sid":"single%d"}`, len(jobs))
+				return
+			}
+			if failBatch {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"messages":[{"type":"FATAL","text":"Error in 'appendcols' command"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"sid":"batch"}`))
+		case strings.HasSuffix(r.URL.Path, "/batch/results"):
+			// the search of hosts has no result in the batch
+			_, _ = w.Write([]byte(`{"results":[{"batch0_count":"3","batch1_avg":"1.5","batch1_count":"3"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			// the field of latency, ignored by the other indicators
+			_, _ = w.Write([]byte(`{"results":[{"count":"7","avg":"7"}]}`))
+		default:
+			_, _ = w.Write([]byte(splunktest.DoneJobStatus))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	envConfig := utils.EnvConfig{MaxConcurrentSearches: 2, BatchSearches: true}
+
+	sliResults, sliSearches := getSLIResults(context.Background(), client, indicators, data, sliConfig, envConfig)
+	for i, value := range []float64{3, 1.5, 7, 7} {
+		if sliResults[i].Metric != indicators[i] || !sliResults[i].Success || sliResults[i].Value != value {
+			t.Fatalf("Expected %v for %s but got %+v", value, indicators[i], sliResults[i])
+		}
+	}
+	batchJobs := 0
+	for _, job := range jobs {
+		if strings.Contains(job, "appendcols") {
+			batchJobs++
+		}
+	}
+	if len(jobs) != 3 || batchJobs != 1 {
+		t.Fatalf("Expected a batch job and the searches of web and hosts but got %v", jobs)
+	}
+	if sliSearches[0].status.Sid != "batch" || sliSearches[1].status.Sid != "batch" || sliSearches[1].params.SearchQuery != sliConfig["latency"].Query {
+		t.Fatalf("Expected the batched indicators to share the job with their own search but got %+v, %+v", sliSearches[0], sliSearches[1])
+	}
+
+	failBatch, jobs = true, nil
+	sliResults, _ = getSLIResults(context.Background(), client, indicators, data, sliConfig, envConfig)
+	for i, sliResult := range sliResults {
+		if !sliResult.Success || sliResult.Value != 7 {
+			t.Fatalf("Expected %s to be searched separately but got %+v", indicators[i], sliResult)
+		}
+	}
+	if len(jobs) != 5 {
+		t.Fatalf("Expected the failed batch job and a job per indicator but got %v", jobs)
+	}
+
+	failBatch, jobs = false, nil
+	_, _ = getSLIResults(context.Background(), client, indicators, data, sliConfig, utils.EnvConfig{MaxConcurrentSearches: 2})
+	if len(jobs) != 4 {
+		t.Fatalf("Expected no batch unless it is enabled but got %v", jobs)
+	}
+}
+
+// Tests that the relative time ranges evaluated in a timezone are batched, and that the timeout of the indicators is kept
+func TestGetSLIResultsBatchedRelativeTimeRange(t *testing.T) {
+	data := &keptnv2.GetSLITriggeredEventData{}
+	indicators := []string{"errors", "warnings", "slow"}
+	sliConfig := map[string]utils.SLIDefinition{
+		"errors":   {Query: "index=main | stats count(eval(level=\"error\")) as count", Earliest: "-1h", Latest: "now"},
+		"warnings": {Query: "index=main | stats count(eval(level=\"warn\")) as count", Earliest: "-1h", Latest: "now"},
+		"slow":     {Query: "index=main | stats count(eval(duration>1)) as count", Earliest: "-1h", Latest: "now", Timeout: 10 * time.Minute},
+	}
+
+	var mutex sync.Mutex
+	var jobs []string
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			params, _ := url.ParseQuery(string(body))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			jobs = append(jobs, params.Get("search"))
+			if strings.Contains(params.Get("search"), "appendcols") {
+				_, _ = w.Write([]byte(`{"sid":"batch"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"sid":"single"}`))
+		case strings.HasSuffix(r.URL.Path, "/batch/results"):
+			_, _ = w.Write([]byte(`{"results":[{"batch0_count":"3","batch1_count":"4"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"5"}]}`))
+		default:
+			_, _ = w.Write([]byte(splunktest.DoneJobStatus))
+		}
+	}))
+	defer splunkServer.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	envConfig := utils.EnvConfig{MaxConcurrentSearches: 2, BatchSearches: true, Timezone: "Europe/Paris", SearchTimeout: 2 * time.Minute}
+
+	sliResults, _ := getSLIResults(context.Background(), client, indicators, data, sliConfig, envConfig)
+	for i, value := range []float64{3, 4, 5} {
+		if !sliResults[i].Success || sliResults[i].Value != value {
+			t.Fatalf("Expected %v for %s but got %+v", value, indicators[i], sliResults[i])
+		}
+	}
+	if len(jobs) != 2 {
+		t.Fatalf("Expected a batch of errors and warnings and a job for slow but got %v", jobs)
+	}
+}
+
+// Tests that the status of the finished event depends on the failure policy
+func TestSetFailedIndicators(t *testing.T) {
+	sliResults := []*keptnv2.SLIResult{
+		{Metric: "first", Value: 1, Success: true},
+		{Metric: "second", Success: false, Message: "no query found for indicator second"},
+	}
+	sliSearches := make([]*sliSearch, len(sliResults))
+
+	eventData := keptnv2.EventData{Status: keptnv2.StatusSucceeded, Result: keptnv2.ResultPass}
+	setFailedIndicators(&eventData, sliResults, sliSearches, utils.SLIFailurePolicyFail)
+	if eventData.Status != keptnv2.StatusErrored || eventData.Result != keptnv2.ResultFailed || !strings.Contains(eventData.Message, "1 of 2 slis : no query found for indicator second") {
+		t.Fatalf("Expected the task to fail but got %+v", eventData)
+	}
+
+	eventData = keptnv2.EventData{Status: keptnv2.StatusSucceeded, Result: keptnv2.ResultPass}
+	setFailedIndicators(&eventData, sliResults, sliSearches, utils.SLIFailurePolicyLighthouse)
+	if eventData.Status != keptnv2.StatusSucceeded || eventData.Result != keptnv2.ResultPass || eventData.Message == "" {
+		t.Fatalf("Expected the task to succeed with a message but got %+v", eventData)
+	}
+
+	eventData = keptnv2.EventData{Status: keptnv2.StatusSucceeded, Result: keptnv2.ResultPass}
+	setFailedIndicators(&eventData, sliResults[:1], sliSearches[:1], utils.SLIFailurePolicyFail)
+	if eventData.Status != keptnv2.StatusSucceeded || eventData.Message != "" {
+		t.Fatalf("Expected the task to succeed but got %+v", eventData)
+	}
+
+	// the skipped indicators are not failures
+	eventData = keptnv2.EventData{Status: keptnv2.StatusSucceeded, Result: keptnv2.ResultPass}
+	skippedResults := []*keptnv2.SLIResult{sliResults[0], {Metric: "third", Success: false, Message: "skipped : no data for indicator third"}}
+	setFailedIndicators(&eventData, skippedResults, []*sliSearch{nil, {skipped: true}}, utils.SLIFailurePolicyFail)
+	if eventData.Status != keptnv2.StatusSucceeded || eventData.Message != "" {
+		t.Fatalf("Expected the task to succeed but got %+v", eventData)
+	}
+
+	// an error whose message looks like a skip is still a failure
+	eventData = keptnv2.EventData{Status: keptnv2.StatusSucceeded, Result: keptnv2.ResultPass}
+	setFailedIndicators(&eventData, skippedResults, []*sliSearch{nil, {}}, utils.SLIFailurePolicyFail)
+	if eventData.Status != keptnv2.StatusErrored || !strings.Contains(eventData.Message, "1 of 2 slis") {
+		t.Fatalf("Expected the task to fail but got %+v", eventData)
+	}
+}
+
 // Tests the handleGetSliTriggered function
 // Tests the handleGetSliTriggered function
 func TestHandleGetSliTriggered(t *testing.T) {
@@ -160,8 +886,8 @@ func TestHandleGetSliTriggered(t *testing.T) {
 		t.Fatalf("Failed to get splunk credentials: %s", err)
 		return
 	}
-	client := utils.ConnectToSplunk(*splunkCreds, true)
-	err = HandleGetSliTriggeredEvent(ddKeptn, *incomingEvent, data, client)
+	client := utils.ConnectToSplunk(*splunkCreds, &tls.Config{InsecureSkipVerify: true})
+	err = HandleGetSliTriggeredEvent(context.Background(), ddKeptn, *incomingEvent, data, env, client)
 
 	if err != nil {
 		t.Fatalf("Error : %v", err)
@@ -204,10 +930,132 @@ func TestHandleGetSliTriggered(t *testing.T) {
 			default:
 				t.Fatalf("Wrong value for the metric %s : %v", sliResult.Metric, sliResult.Value)
 			}
+			// the search job of each indicator is described in the labels
+			if !strings.HasPrefix(respData.Labels[jobLabelPrefix+sliResult.Metric], "sid=") {
+				t.Fatalf("Expected a label describing the job of %s but got %v", sliResult.Metric, respData.Labels)
+			}
+		}
+	}
+}
+
+// Tests that an invalid sli file fails the get-sli task with the error in the message of the finished event
+func TestHandleGetSliTriggeredInvalidSLIFile(t *testing.T) {
+	invalidSLIFiles := map[string]string{
+		"indicators:\n  number_of_errors:\n    query: index=main error | stats count\n    feild: count\n": "field feild not found",
+		"indicators:\n  number_of_errors:\n    query: index=main error | stats count\n    timeout: 5\n":   "invalid timeout 5 : a duration needs a unit",
+	}
+	for content, expected := range invalidSLIFiles {
+		sliFile := filepath.Join(t.TempDir(), "sli.yaml")
+		if err := os.WriteFile(sliFile, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		resourceServiceServer, err := buildMockResourceServiceServer(sliFile, shipyardFilePath, sloFilePath, remediationFilePath)
+		if err != nil {
+			t.Fatalf("Error reading sli file : %v", err)
+		}
+		defer resourceServiceServer.Close()
+
+		ddKeptn, incomingEvent, err := initializeTestObjects(getSliTriggeredEventFile, resourceServiceServer.URL+"/api/resource-service")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := &keptnv2.GetSLITriggeredEventData{}
+		if err := incomingEvent.DataAs(data); err != nil {
+			t.Fatalf("Error while getting keptn event data : %v", err)
+		}
+
+		// the sli file is read before any search
+		client := splunk.NewClientAuthenticatedByToken(&http.Client{}, "localhost", "8089", "apiToken", &tls.Config{InsecureSkipVerify: true})
+		err = HandleGetSliTriggeredEvent(context.Background(), ddKeptn, *incomingEvent, data, utils.EnvConfig{}, client)
+		if err == nil {
+			t.Fatalf("Expected an error for the sli file %q", content)
+		}
+
+		sentEvents := ddKeptn.EventSender.(*fake.EventSender).SentEvents
+		finishedEvent := sentEvents[len(sentEvents)-1]
+		var respData keptnv2.GetSLIFinishedEventData
+		err = datacodec.Decode(context.Background(), finishedEvent.DataMediaType(), finishedEvent.Data(), &respData)
+		if err != nil {
+			t.Fatalf("Unable to decode data from the event : %v", err)
+		}
+		if respData.Status != keptnv2.StatusErrored || !strings.Contains(respData.Message, expected) {
+			t.Fatalf("Expected an errored event with %q in its message but got %s : %s", expected, respData.Status, respData.Message)
 		}
 	}
 }
 
+// Tests that splunk searches exactly the time range reported in the get-sli.finished event, whatever the timezone
+func TestHandleGetSliTriggeredTimeRange(t *testing.T) {
+	resourceServiceServer, err := buildMockResourceServiceServer(sliFilePath, shipyardFilePath, sloFilePath, remediationFilePath)
+	if err != nil {
+		t.Fatalf("Error reading sli file : %v", err)
+	}
+	defer resourceServiceServer.Close()
+
+	var mutex sync.Mutex
+	var params url.Values
+	splunkServer := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			mutex.Lock()
+			params, _ = url.ParseQuery(string(body))
+			mutex.Unlock()
+			_, _ = w.Write([]byte(`{"sid":"10"}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"1"}]}`))
+		default:
+			_, _ = w.Write([]byte(splunktest.DoneJobStatus))
+		}
+	}))
+	defer splunkServer.Close()
+
+	ddKeptn, incomingEvent, err := initializeTestObjects(getSliTriggeredEventFile, resourceServiceServer.URL+"/api/resource-service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := &keptnv2.GetSLITriggeredEventData{}
+	if err = incomingEvent.DataAs(data); err != nil {
+		t.Fatalf("Error while getting keptn event data : %v", err)
+	}
+	// a start with a fraction of second and an end with an offset
+	data.GetSLI.Start = "2021-01-15T15:04:45.123Z"
+	data.GetSLI.End = "2021-01-15T16:09:45+01:00"
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(strings.Split(splunkServer.URL, ":")[1], "//")[1],
+		strings.Split(splunkServer.URL, ":")[2],
+		"apiToken",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	env := utils.EnvConfig{MaxConcurrentSearches: 1, Timezone: "America/New_York"}
+	err = HandleGetSliTriggeredEvent(context.Background(), ddKeptn, *incomingEvent, data, env, client)
+	if err != nil {
+		t.Fatalf("Error : %v", err)
+	}
+
+	finishedEvent := ddKeptn.EventSender.(*fake.EventSender).SentEvents[1]
+	var respData keptnv2.GetSLIFinishedEventData
+	err = datacodec.Decode(context.Background(), finishedEvent.DataMediaType(), finishedEvent.Data(), &respData)
+	if err != nil {
+		t.Fatalf("Unable to decode data from the event : %v", err)
+	}
+
+	start, _ := time.Parse(time.RFC3339Nano, respData.GetSLI.Start)
+	end, _ := time.Parse(time.RFC3339Nano, respData.GetSLI.End)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if params.Get("earliest_time") != "1610723085.123" || float64(start.UnixMilli())/1000 != 1610723085.123 {
+		t.Fatalf("Expected splunk to search from %s but got %s", respData.GetSLI.Start, params.Get("earliest_time"))
+	}
+	if params.Get("latest_time") != "1610723385" || end.Unix() != 1610723385 {
+		t.Fatalf("Expected splunk to search until %s but got %s", respData.GetSLI.End, params.Get("latest_time"))
+	}
+}
+
 // Builds a fake splunk server able to respond when we try to list fired alerts and instances of fired alerts
 func buildMockSplunkServer(t *testing.T) *httptest.Server {
 
@@ -4,7 +4,11 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	// the timezone database is embedded, the image having none, so that SP_TIMEZONE can be any IANA timezone
+	_ "time/tzdata"
 
 	"github.com/keptn-sandbox/splunk-sli-provider/alerts"
 	"github.com/keptn-sandbox/splunk-sli-provider/handler"
@@ -144,7 +148,7 @@ func ProcessKeptnCloudEvent(ctx context.Context, event cloudevents.Event) error
 		eventDatav2.ConfigureMonitoring.Type = eventDatav1.Type
 		event.SetType(keptnv2.GetTriggeredEventType(keptnv2.ConfigureMonitoringTaskName))
 
-		return handleConfigureMonitoringTriggeredEvent(ddKeptn, event, eventDatav2, env, splunkClient, pollingSystemHasBeenStarted)
+		return handleConfigureMonitoringTriggeredEvent(ctx, ddKeptn, event, eventDatav2, env, splunkClient, pollingSystemHasBeenStarted)
 
 	// -------------------------------------------------------
 	// sh.keptn.event.get-sli (sent by lighthouse-service to fetch SLIs from the sli provider)
@@ -157,7 +161,7 @@ func ProcessKeptnCloudEvent(ctx context.Context, event cloudevents.Event) error
 			return fmt.Errorf("Enable to parse keptn cloud event payload %w", err)
 		}
 
-		return handleGetSliTriggeredEvent(ddKeptn, event, eventData, splunkClient)
+		return handleGetSliTriggeredEvent(ctx, ddKeptn, event, eventData, env, splunkClient)
 
 	// -------------------------------------------------------
 	// Unknown Event -> Throw Error!
@@ -196,11 +200,36 @@ func main() {
 	if err != nil {
 		logger.Fatalf("Failed to get splunk credentials: %s", err)
 	}
+	tlsConfig, err := utils.GetTLSConfig(env)
+	if err != nil {
+		logger.Fatalf("Failed to configure TLS: %s", err)
+	}
+
 	// connect to splunk
-	splunkClient = utils.ConnectToSplunk(*splunkCreds, true)
+	splunkClient = utils.ConnectToSplunk(*splunkCreds, tlsConfig)
+	splunkClient.RetryPolicy = utils.GetRetryPolicy(env)
+
+	err = utils.CheckNamespaces(env)
+	if err != nil {
+		logger.Fatalf("Failed to configure splunk namespaces: %s", err)
+	}
+	splunkClient.Namespace = utils.GetNamespace(env, "")
+
+	err = utils.CheckSLIConfig(env)
+	if err != nil {
+		logger.Fatalf("Invalid SLI configuration: %s", err)
+	}
+	err = utils.CheckAlertConfig(env)
+	if err != nil {
+		logger.Fatalf("Invalid alert configuration: %s", err)
+	}
+
+	// cancelled on shutdown so that pending splunk requests are aborted
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// start polling if alerts are configured
-	alertsList, err := splunkalerts.ListAlertsNames(splunkClient)
+	alertsList, err := splunkalerts.ListAlertsNames(utils.WithAlertsNamespace(ctx, env), splunkClient)
 	if err != nil {
 		logger.Fatalf("Failed to get alerts list: %s", err)
 	}
@@ -213,20 +242,21 @@ func main() {
 
 		go func() {
 			logger.Info("Start polling for triggered alerts ...")
-			alerts.FiringAlertsPoll(splunkClient, nil, keptnOptions, env)
+			alerts.FiringAlertsPoll(ctx, splunkClient, nil, keptnOptions, env)
 		}()
 		pollingSystemHasBeenStarted = true
 		break
 
 	}
 
-	CloudEventListener(os.Args[1:])
+	CloudEventListener(ctx, os.Args[1:])
 }
 
 /**
  * Opens up a listener on localhost:port/path and passes incoming requets to gotEvent
+ * The listener stops, and in-flight events are cancelled, once ctx is done
  */
-func CloudEventListener(args []string) {
+func CloudEventListener(ctx context.Context, args []string) {
 	switch env.Env {
 	case "local":
 		err := godotenv.Load(".env.local")
@@ -251,7 +281,6 @@ func CloudEventListener(args []string) {
 	logger.Info("Starting splunk-sli-provider...", env.Env)
 	logger.Infof("    on Port = %d; Path=%s", env.Port, env.Path)
 
-	ctx := context.Background()
 	ctx = cloudevents.WithEncodingStructured(ctx)
 
 	logger.Infof("Creating new http handler")
@@ -268,6 +297,10 @@ func CloudEventListener(args []string) {
 	}
 
 	logger.Infof("Starting receiver")
-	logger.Fatal(c.StartReceiver(ctx, processKeptnCloudEvent).Error())
+	err = c.StartReceiver(ctx, processKeptnCloudEvent)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+	logger.Info("Receiver stopped")
 
 }
@@ -51,11 +51,11 @@ func TestProcessKeptnCloudEvent(t *testing.T) {
 	*calledSLI = false
 	*calledConfig = false
 
-	handleConfigureMonitoringTriggeredEvent = func(ddKeptn *keptnv2.Keptn, incomingEvent event.Event, data *keptnv2.ConfigureMonitoringTriggeredEventData, env utils.EnvConfig, client *splunk.SplunkClient, pollingSystemHasBeenStarted bool) error {
+	handleConfigureMonitoringTriggeredEvent = func(ctx context.Context, ddKeptn *keptnv2.Keptn, incomingEvent event.Event, data *keptnv2.ConfigureMonitoringTriggeredEventData, env utils.EnvConfig, client *splunk.SplunkClient, pollingSystemHasBeenStarted bool) error {
 		*calledConfig = true
 		return nil
 	}
-	handleGetSliTriggeredEvent = func(ddKeptn *keptnv2.Keptn, incomingEvent event.Event, data *keptnv2.GetSLITriggeredEventData, client *splunk.SplunkClient) error {
+	handleGetSliTriggeredEvent = func(ctx context.Context, ddKeptn *keptnv2.Keptn, incomingEvent event.Event, data *keptnv2.GetSLITriggeredEventData, env utils.EnvConfig, client *splunk.SplunkClient) error {
 		*calledSLI = true
 		return nil
 	}
@@ -86,7 +86,7 @@ func TestCloudEventListener(t *testing.T) {
 	}
 
 	args := []string{}
-	go CloudEventListener(args)
+	go CloudEventListener(context.Background(), args)
 	//sleep for 2 seconds to let the previous go routine the time to start listening for events
 	time.Sleep(time.Duration(2) * time.Second)
 	err := sendTestCloudEvent("test/events/get-sli.triggered.json")
@@ -1,10 +1,12 @@
 package alerts
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http/httputil"
+	"net/url"
 	"strconv"
 	"strings"
 
@@ -37,6 +39,11 @@ type AlertParams struct {
 	AlertSuppressPeriod string
 	Actions             string
 	WebhookUrl          string
+	// sharing of the saved search : user, app or global. The ACL is left unchanged if empty
+	Sharing string
+	// comma separated lists of the roles allowed to read and write the saved search
+	ReadRoles  string
+	WriteRoles string
 }
 
 type splunkAlertEntry struct {
@@ -81,13 +88,13 @@ type Content struct {
 }
 
 // Creates a new alert from saved search
-func CreateAlert(client *splunk.SplunkClient, spAlert *AlertRequest) error {
+func CreateAlert(ctx context.Context, client *splunk.SplunkClient, spAlert *AlertRequest) error {
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, savedSearchesPath)
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, savedSearchesPath)
 	spAlert.Params.SearchQuery = utils.ValidateAlertQuery(spAlert.Params.SearchQuery)
 
-	resp, err := PostAlert(client, spAlert)
+	resp, err := PostAlert(ctx, client, endpoint, spAlert)
 
 	var respDump []byte
 	var errDump error
@@ -105,32 +112,55 @@ func CreateAlert(client *splunk.SplunkClient, spAlert *AlertRequest) error {
 	body, err := io.ReadAll(resp.Body)
 	// handle error
 	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return fmt.Errorf("alert creation : http error :  %s \nResponse : %v", status, string(respDump))
-		default:
-			return fmt.Errorf("alert creation : http error :  %s \nResponse : %v", resp.Status, string(respDump))
-		}
+		return fmt.Errorf("alert creation : %w \nResponse : %v", splunk.NewSplunkError(resp, body), string(respDump))
 	}
 
 	if err != nil {
 		return fmt.Errorf("alert creation : error while getting the body of the post request : %s", err)
 	}
 
+	if spAlert.Params.Sharing != "" {
+		return SetAlertACL(ctx, client, spAlert)
+	}
+
+	return nil
+}
+
+// Sets the sharing and the permissions of an existing saved search
+func SetAlertACL(ctx context.Context, client *splunk.SplunkClient, spAlert *AlertRequest) error {
+
+	// create the endpoint for the request
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, savedSearchesPath+url.PathEscape(spAlert.Params.Name)+"/acl")
+
+	// setting the same ACL twice has no side effect, so the request can be retried
+	resp, err := PostAlertACL(splunk.WithIdempotentRequest(ctx), client, endpoint, spAlert)
+	if err != nil {
+		return fmt.Errorf("alert ACL : error while making the post request : %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	// handle error
+	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
+		return fmt.Errorf("alert ACL : %w", splunk.NewSplunkError(resp, body))
+	}
+	if err != nil {
+		return fmt.Errorf("alert ACL : error while getting the body of the post request : %w", err)
+	}
+
 	return nil
 }
 
 // Removes an existing saved search
-func RemoveAlert(client *splunk.SplunkClient, alertName string) error {
+func RemoveAlert(ctx context.Context, client *splunk.SplunkClient, alertName string) error {
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, savedSearchesPath+alertName)
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, savedSearchesPath+alertName)
 
 	splunkAlert := AlertRequest{}
 	splunkAlert.Params.Name = alertName
 
-	resp, err := DeleteAlert(client, &splunkAlert)
+	resp, err := DeleteAlert(ctx, client, endpoint, &splunkAlert)
 
 	var respDump []byte
 	var errDump error
@@ -148,13 +178,7 @@ func RemoveAlert(client *splunk.SplunkClient, alertName string) error {
 	body, err := io.ReadAll(resp.Body)
 	// handle error
 	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return fmt.Errorf("alert Removing : http error :  %s \nResponse : %v", status, string(respDump))
-		default:
-			return fmt.Errorf("alert Removing : http error :  %s \nResponse : %v", resp.Status, string(respDump))
-		}
+		return fmt.Errorf("alert Removing : %w \nResponse : %v", splunk.NewSplunkError(resp, body), string(respDump))
 	}
 
 	if err != nil {
@@ -165,14 +189,14 @@ func RemoveAlert(client *splunk.SplunkClient, alertName string) error {
 }
 
 // List saved searches
-func ListAlertsNames(client *splunk.SplunkClient) (splunkAlertList, error) {
+func ListAlertsNames(ctx context.Context, client *splunk.SplunkClient) (splunkAlertList, error) {
 
 	var alertList splunkAlertList
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, savedSearchesPath)
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, savedSearchesPath)
 
-	resp, err := GetAlerts(client)
+	resp, err := GetAlerts(ctx, client, endpoint)
 
 	var respDump []byte
 	var errDump error
@@ -190,13 +214,7 @@ func ListAlertsNames(client *splunk.SplunkClient) (splunkAlertList, error) {
 	body, err := io.ReadAll(resp.Body)
 	// handle error
 	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return alertList, fmt.Errorf("alerts' names listing : http error :  %s \nResponse : %v", status, string(respDump))
-		default:
-			return alertList, fmt.Errorf("alerts' names listing : http error :  %s \nResponse : %v", resp.Status, string(respDump))
-		}
+		return alertList, fmt.Errorf("alerts' names listing : %w \nResponse : %v", splunk.NewSplunkError(resp, body), string(respDump))
 	}
 
 	if err != nil {
@@ -211,14 +229,14 @@ func ListAlertsNames(client *splunk.SplunkClient) (splunkAlertList, error) {
 	return alertList, nil
 }
 
-func GetTriggeredAlerts(client *splunk.SplunkClient) (TriggeredAlerts, error) {
+func GetTriggeredAlerts(ctx context.Context, client *splunk.SplunkClient) (TriggeredAlerts, error) {
 
 	var triggeredAlerts TriggeredAlerts
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, triggeredAlertsPath)
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, triggeredAlertsPath)
 
-	resp, err := GetAlerts(client)
+	resp, err := GetAlerts(ctx, client, endpoint)
 
 	var respDump []byte
 	var errDump error
@@ -236,13 +254,7 @@ func GetTriggeredAlerts(client *splunk.SplunkClient) (TriggeredAlerts, error) {
 	body, err := io.ReadAll(resp.Body)
 	// handle error
 	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : http error :  %s \nResponse : %s", status, string(respDump))
-		default:
-			return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : http error :  %s \nResponse : %s", resp.Status, string(respDump))
-		}
+		return triggeredAlerts, fmt.Errorf("triggered alerts' names listing : %w \nResponse : %s", splunk.NewSplunkError(resp, body), string(respDump))
 	}
 
 	if err != nil {
@@ -257,14 +269,14 @@ func GetTriggeredAlerts(client *splunk.SplunkClient) (TriggeredAlerts, error) {
 	return triggeredAlerts, nil
 }
 
-func GetInstancesOfTriggeredAlert(client *splunk.SplunkClient, link string) (TriggeredInstances, error) {
+func GetInstancesOfTriggeredAlert(ctx context.Context, client *splunk.SplunkClient, link string) (TriggeredInstances, error) {
 
 	var triggeredInstances TriggeredInstances
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, strings.TrimPrefix(link, "/"))
+	endpoint := splunk.CreateEndpoint(client, strings.TrimPrefix(link, "/"))
 
-	resp, err := GetAlerts(client)
+	resp, err := GetAlerts(ctx, client, endpoint)
 
 	var respDump []byte
 	var errDump error
@@ -282,13 +294,7 @@ func GetInstancesOfTriggeredAlert(client *splunk.SplunkClient, link string) (Tri
 	body, err := io.ReadAll(resp.Body)
 	// handle error
 	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return triggeredInstances, fmt.Errorf("triggered instances' names listing : http error :  %s \nResponse : %s, LINK : %s", status, string(respDump), client.Endpoint)
-		default:
-			return triggeredInstances, fmt.Errorf("triggered instances' names listing : http error :  %s \nResponse : %s, LINK : %s", status, string(respDump), client.Endpoint)
-		}
+		return triggeredInstances, fmt.Errorf("triggered instances' names listing : %w \nResponse : %s", splunk.NewSplunkError(resp, body), string(respDump))
 	}
 
 	if err != nil {
@@ -1,28 +1,52 @@
 package alerts
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 
 	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
 )
 
-func PostAlert(client *splunk.SplunkClient, spAlert *AlertRequest) (*http.Response, error) {
+func PostAlert(ctx context.Context, client *splunk.SplunkClient, endpoint string, spAlert *AlertRequest) (*http.Response, error) {
 
-	return HttpAlertRequest(client, http.MethodPost, spAlert)
+	return HttpAlertRequest(ctx, client, http.MethodPost, endpoint, spAlert)
 }
 
-func GetAlerts(client *splunk.SplunkClient) (*http.Response, error) {
+func GetAlerts(ctx context.Context, client *splunk.SplunkClient, endpoint string) (*http.Response, error) {
 
-	return HttpAlertRequest(client, http.MethodGet, nil)
+	return HttpAlertRequest(ctx, client, http.MethodGet, endpoint, nil)
 }
 
-func DeleteAlert(client *splunk.SplunkClient, spAlert *AlertRequest) (*http.Response, error) {
+func DeleteAlert(ctx context.Context, client *splunk.SplunkClient, endpoint string, spAlert *AlertRequest) (*http.Response, error) {
 
-	return HttpAlertRequest(client, "DELETE", spAlert)
+	return HttpAlertRequest(ctx, client, "DELETE", endpoint, spAlert)
 }
 
-func HttpAlertRequest(client *splunk.SplunkClient, method string, spAlert *AlertRequest) (*http.Response, error) {
+func PostAlertACL(ctx context.Context, client *splunk.SplunkClient, endpoint string, spAlert *AlertRequest) (*http.Response, error) {
+
+	// the saved search is owned by the owner of the namespace of the request, or by nobody when shared globally
+	owner := splunk.NamespaceOf(ctx, client).Owner
+	if owner == "" {
+		owner = "nobody"
+	}
+
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	params.Add("owner", owner)
+	params.Add("sharing", spAlert.Params.Sharing)
+	if spAlert.Params.ReadRoles != "" {
+		params.Add("perms.read", spAlert.Params.ReadRoles)
+	}
+	if spAlert.Params.WriteRoles != "" {
+		params.Add("perms.write", spAlert.Params.WriteRoles)
+	}
+
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	return splunk.MakeHttpRequest(ctx, client, http.MethodPost, endpoint, headers, params)
+}
+
+func HttpAlertRequest(ctx context.Context, client *splunk.SplunkClient, method string, endpoint string, spAlert *AlertRequest) (*http.Response, error) {
 
 	if spAlert == nil {
 		spAlert = &AlertRequest{}
@@ -82,5 +106,5 @@ func HttpAlertRequest(client *splunk.SplunkClient, method string, spAlert *Alert
 	if spAlert.Headers == nil {
 		spAlert.Headers = map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
 	}
-	return splunk.MakeHttpRequest(client, method, spAlert.Headers, params)
+	return splunk.MakeHttpRequest(ctx, client, method, endpoint, spAlert.Headers, params)
 }
@@ -5,99 +5,113 @@ import (
 	"net/http"
 )
 
+// SplunkClient holds the connection settings of a splunk instance.
+// Apart from its guarded session key, it is not modified by requests and can be shared between goroutines
 type SplunkClient struct {
 	Client     *http.Client
 	Host       string
 	Port       string
-	Endpoint   string
 	Token      string
 	Username   string
 	Password   string
 	SessionKey string
+	// full url of the REST API of splunk (scheme, host, port and path prefix), e.g. https://gateway/splunk-api/
+	// if empty, the url is built from Host and Port
+	BaseURL string
+	// owner and app of the requests, the global services/ endpoints are used if empty
+	Namespace Namespace
 	// if true, ssl verification is skipped
 	SkipSSL bool
+	// how transient failures are retried, nil disables retries
+	RetryPolicy *RetryPolicy
+	// if true, the client logs in with Username and Password and uses the returned session key
+	LoginAuth bool
+	session   loginSession
 }
 
 // create a new Client
-func NewClient(client *http.Client, host string, port string, token string, username string, password string, sessionKey string, skipSSL bool) *SplunkClient {
-	if skipSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
-	}
+func NewClient(client *http.Client, host string, port string, token string, username string, password string, sessionKey string, tlsConfig *tls.Config) *SplunkClient {
+	setTLSConfig(client, tlsConfig)
 
 	return &SplunkClient{
-		Client:     client,
-		Host:       host,
-		Port:       port,
-		Token:      token,
-		Username:   username,
-		Password:   password,
-		SessionKey: sessionKey,
-		SkipSSL:    skipSSL,
+		Client:      client,
+		Host:        host,
+		Port:        port,
+		Token:       token,
+		Username:    username,
+		Password:    password,
+		SessionKey:  sessionKey,
+		SkipSSL:     tlsConfig != nil && tlsConfig.InsecureSkipVerify,
+		RetryPolicy: DefaultRetryPolicy(),
 	}
 }
 
 // create a new client that could connect with authentication tokens
-func NewClientAuthenticatedByToken(client *http.Client, host string, port string, token string, skipSSL bool) *SplunkClient {
-	if skipSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
-	}
+func NewClientAuthenticatedByToken(client *http.Client, host string, port string, token string, tlsConfig *tls.Config) *SplunkClient {
+	setTLSConfig(client, tlsConfig)
 
 	return &SplunkClient{
-		Client:     client,
-		Host:       host,
-		Port:       port,
-		Token:      token,
-		Username:   "",
-		Password:   "",
-		SessionKey: "",
-		SkipSSL:    skipSSL,
+		Client:      client,
+		Host:        host,
+		Port:        port,
+		Token:       token,
+		Username:    "",
+		Password:    "",
+		SessionKey:  "",
+		SkipSSL:     tlsConfig != nil && tlsConfig.InsecureSkipVerify,
+		RetryPolicy: DefaultRetryPolicy(),
 	}
 }
 
 // create a new client that could connect with authentication sessionKey
-func NewClientAuthenticatedBySessionKey(client *http.Client, host string, port string, sessionKey string, skipSSL bool) *SplunkClient {
-	if skipSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
-	}
+func NewClientAuthenticatedBySessionKey(client *http.Client, host string, port string, sessionKey string, tlsConfig *tls.Config) *SplunkClient {
+	setTLSConfig(client, tlsConfig)
 
 	return &SplunkClient{
-		Client:     client,
-		Host:       host,
-		Port:       port,
-		SessionKey: sessionKey,
-		Token:      "",
-		Username:   "",
-		Password:   "",
-		SkipSSL:    skipSSL,
+		Client:      client,
+		Host:        host,
+		Port:        port,
+		SessionKey:  sessionKey,
+		Token:       "",
+		Username:    "",
+		Password:    "",
+		SkipSSL:     tlsConfig != nil && tlsConfig.InsecureSkipVerify,
+		RetryPolicy: DefaultRetryPolicy(),
 	}
 }
 
 // create a new client with basic authentication method
-func NewBasicAuthenticatedClient(client *http.Client, host string, port string, username string, password string, skipSSL bool) *SplunkClient {
-	if skipSSL {
-		tr := &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-		}
-		client.Transport = tr
+func NewBasicAuthenticatedClient(client *http.Client, host string, port string, username string, password string, tlsConfig *tls.Config) *SplunkClient {
+	setTLSConfig(client, tlsConfig)
+
+	return &SplunkClient{
+		Client:      client,
+		Host:        host,
+		Port:        port,
+		Username:    username,
+		Password:    password,
+		Token:       "",
+		SessionKey:  "",
+		SkipSSL:     tlsConfig != nil && tlsConfig.InsecureSkipVerify,
+		RetryPolicy: DefaultRetryPolicy(),
 	}
+}
+
+// create a new client that logs in with a username and a password and authenticates with the returned session key
+// the session key is renewed whenever it expires
+func NewClientAuthenticatedByLogin(client *http.Client, host string, port string, username string, password string, tlsConfig *tls.Config) *SplunkClient {
+	setTLSConfig(client, tlsConfig)
 
 	return &SplunkClient{
-		Client:     client,
-		Host:       host,
-		Port:       port,
-		Username:   username,
-		Password:   password,
-		Token:      "",
-		SessionKey: "",
-		SkipSSL:    skipSSL,
+		Client:      client,
+		Host:        host,
+		Port:        port,
+		Username:    username,
+		Password:    password,
+		Token:       "",
+		SessionKey:  "",
+		SkipSSL:     tlsConfig != nil && tlsConfig.InsecureSkipVerify,
+		RetryPolicy: DefaultRetryPolicy(),
+		LoginAuth:   true,
 	}
 }
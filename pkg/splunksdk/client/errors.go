@@ -0,0 +1,143 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrorKind classifies the errors returned by splunk
+type ErrorKind string
+
+const (
+	ErrorKindAuthentication ErrorKind = "authentication"
+	ErrorKindInvalidSearch  ErrorKind = "invalid search"
+	ErrorKindQuota          ErrorKind = "quota"
+	ErrorKindNotFound       ErrorKind = "not found"
+	ErrorKindOther          ErrorKind = "other"
+)
+
+// Message is an entry of the messages returned by the splunk REST API
+type Message struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SplunkError is returned when splunk answers a request with an http error
+type SplunkError struct {
+	StatusCode int
+	Status     string
+	// all the messages of the response body
+	Messages []Message
+	// path of the REST endpoint
+	Path string
+	// sid of the search job concerned by the request, if any
+	Sid string
+}
+
+// NewSplunkError creates the error corresponding to the given response and its body
+func NewSplunkError(resp *http.Response, body []byte) *SplunkError {
+	splunkErr := &SplunkError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Messages:   parseMessages(body),
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		splunkErr.Path = resp.Request.URL.Path
+		splunkErr.Sid = sidFromPath(splunkErr.Path)
+	}
+	return splunkErr
+}
+
+func (e *SplunkError) Error() string {
+	var sb strings.Builder
+	status := e.Status
+	if status == "" {
+		status = fmt.Sprint(e.StatusCode)
+	}
+	sb.WriteString("http error " + status)
+	if e.Path != "" {
+		sb.WriteString(" on " + e.Path)
+	}
+	if e.Sid != "" {
+		sb.WriteString(" (sid " + e.Sid + ")")
+	}
+	for i, message := range e.Messages {
+		if i == 0 {
+			sb.WriteString(" : ")
+		} else {
+			sb.WriteString(" ; ")
+		}
+		sb.WriteString(message.Type + " " + message.Text)
+	}
+	return sb.String()
+}
+
+// Kind classifies the error from its status code and messages
+func (e *SplunkError) Kind() ErrorKind {
+	for _, message := range e.Messages {
+		text := strings.ToLower(message.Text)
+		if strings.Contains(text, "quota") || strings.Contains(text, "maximum number of concurrent") {
+			return ErrorKindQuota
+		}
+	}
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrorKindAuthentication
+	case http.StatusBadRequest:
+		return ErrorKindInvalidSearch
+	case http.StatusNotFound:
+		return ErrorKindNotFound
+	case http.StatusTooManyRequests:
+		return ErrorKindQuota
+	}
+	return ErrorKindOther
+}
+
+// ErrorKindOf returns the kind of the splunk error wrapped in err, ErrorKindOther if there is none
+func ErrorKindOf(err error) ErrorKind {
+	var splunkErr *SplunkError
+	if errors.As(err, &splunkErr) {
+		return splunkErr.Kind()
+	}
+	return ErrorKindOther
+}
+
+// HandleHttpError returns the messages of the body of an http error
+func HandleHttpError(body []byte) (string, error) {
+
+	messages := parseMessages(body)
+	if len(messages) == 0 {
+		return "", fmt.Errorf("incorrect format")
+	}
+
+	texts := make([]string, 0, len(messages))
+	for _, message := range messages {
+		texts = append(texts, message.Text)
+	}
+	return strings.Join(texts, " ; "), nil
+}
+
+// return the messages of the body of a splunk response
+func parseMessages(body []byte) []Message {
+	var bodyJson struct {
+		Messages []Message `json:"messages"`
+	}
+	if err := json.Unmarshal(body, &bodyJson); err != nil {
+		return nil
+	}
+	return bodyJson.Messages
+}
+
+// return the sid of the search job in the path of a jobs endpoint
+func sidFromPath(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 1; i < len(segments)-1; i++ {
+		if segments[i] == "jobs" && (segments[i-1] == "search" || segments[i-1] == "v2") {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewSplunkError(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"messages":[{"type":"FATAL","text":"Error in 'search' command: Unable to parse the search"},{"type":"WARN","text":"unknown field"}]}`))
+	}))
+	defer server.Close()
+	client := buildTestClient(server, nil)
+
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodGet, CreateEndpoint(client, "services/search/v2/jobs/1689673231.191/results"), nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	err = fmt.Errorf("error while handling the results : %w", NewSplunkError(resp, body))
+
+	var splunkErr *SplunkError
+	if !errors.As(err, &splunkErr) {
+		t.Fatalf("Expected a SplunkError to be wrapped in %v", err)
+	}
+	if splunkErr.StatusCode != http.StatusBadRequest || len(splunkErr.Messages) != 2 || splunkErr.Messages[1].Type != "WARN" {
+		t.Fatalf("Expected the status and all the messages to be kept but got %+v", splunkErr)
+	}
+	if splunkErr.Path != "/services/search/v2/jobs/1689673231.191/results" || splunkErr.Sid != "1689673231.191" {
+		t.Fatalf("Expected the path and sid of the request but got %v and %v", splunkErr.Path, splunkErr.Sid)
+	}
+	if !strings.Contains(err.Error(), "Unable to parse the search") || !strings.Contains(err.Error(), "unknown field") {
+		t.Fatalf("Expected all the messages in the error but got %v", err)
+	}
+	if ErrorKindOf(err) != ErrorKindInvalidSearch {
+		t.Fatalf("Expected an invalid search but got %v", ErrorKindOf(err))
+	}
+}
+
+func TestSplunkErrorKind(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		text       string
+		expected   ErrorKind
+	}{
+		{http.StatusUnauthorized, "call not properly authenticated", ErrorKindAuthentication},
+		{http.StatusForbidden, "You do not have permission", ErrorKindAuthentication},
+		{http.StatusBadRequest, "Unknown search command 'foo'", ErrorKindInvalidSearch},
+		{http.StatusNotFound, "Unknown sid", ErrorKindNotFound},
+		{http.StatusTooManyRequests, "", ErrorKindQuota},
+		{http.StatusServiceUnavailable, "The maximum number of concurrent historical searches has been reached", ErrorKindQuota},
+		{http.StatusBadRequest, "Search not executed: disk usage quota has been reached", ErrorKindQuota},
+		{http.StatusInternalServerError, "Internal error", ErrorKindOther},
+	}
+	for _, test := range tests {
+		splunkErr := &SplunkError{StatusCode: test.statusCode, Messages: []Message{{Type: "ERROR", Text: test.text}}}
+		if kind := splunkErr.Kind(); kind != test.expected {
+			t.Fatalf("%v %s : expected %v but got %v", test.statusCode, test.text, test.expected, kind)
+		}
+	}
+
+	if ErrorKindOf(fmt.Errorf("connection refused")) != ErrorKindOther {
+		t.Fatalf("Expected errors not returned by splunk to be of kind %v", ErrorKindOther)
+	}
+}
+
+func TestHandleHttpError(t *testing.T) {
+	status, err := HandleHttpError([]byte(`{"messages":[{"type":"ERROR","text":"first"},{"type":"ERROR","text":"second"}]}`))
+	if err != nil || status != "first ; second" {
+		t.Fatalf("Expected all the messages but got %v, %v", status, err)
+	}
+
+	_, err = HandleHttpError([]byte(`<html>Bad Gateway</html>`))
+	if err == nil {
+		t.Fatalf("Expected an error for a body without messages")
+	}
+}
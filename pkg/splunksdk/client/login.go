@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const loginPath = "services/auth/login"
+
+// session key obtained by logging in with the username and password of a client
+type loginSession struct {
+	mutex sync.Mutex
+	key   string
+}
+
+// Login authenticates to splunk with the username and password of the client and returns a new session key
+func Login(ctx context.Context, client *SplunkClient) (string, error) {
+
+	params := url.Values{}
+	params.Add("username", client.Username)
+	params.Add("password", client.Password)
+	params.Add("output_mode", "json")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, CreateEndpoint(client, loginPath), strings.NewReader(params.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := client.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("login : error while making the post request : %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	// handle error
+	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
+		return "", fmt.Errorf("login : %w", NewSplunkError(resp, body))
+	}
+	if err != nil {
+		return "", fmt.Errorf("login : error while getting the body of the post request : %w", err)
+	}
+
+	var session struct {
+		SessionKey string `json:"sessionKey"`
+	}
+	err = json.Unmarshal(body, &session)
+	if err != nil {
+		return "", fmt.Errorf("login : could not read the session key : %w", err)
+	}
+	if session.SessionKey == "" {
+		return "", fmt.Errorf("login : no session key found")
+	}
+
+	return session.SessionKey, nil
+}
+
+// return the cached session key of the client, a new one is created if there is none or if the cached one is staleKey
+func getSessionKey(ctx context.Context, client *SplunkClient, staleKey string) (string, error) {
+	client.session.mutex.Lock()
+	defer client.session.mutex.Unlock()
+
+	// another request may already have renewed the session
+	if client.session.key != "" && client.session.key != staleKey {
+		return client.session.key, nil
+	}
+
+	key, err := Login(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	client.session.key = key
+
+	return key, nil
+}
@@ -0,0 +1,131 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// build a test server which creates a new session key at each login
+// and only accepts requests authenticated with the latest one
+func buildLoginServer() (*httptest.Server, *int32) {
+	var logins int32
+	var latestKey atomic.Value
+	latestKey.Store("")
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, loginPath) {
+			if r.FormValue("username") != "admin" || r.FormValue("password") != "password" {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"messages":[{"type":"WARN","text":"Login failed"}]}`))
+				return
+			}
+			key := fmt.Sprintf("key%d", atomic.AddInt32(&logins, 1))
+			latestKey.Store(key)
+			_, _ = w.Write([]byte(`{"sessionKey":"` + key + `"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Splunk "+latestKey.Load().(string) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"messages":[{"type":"WARN","text":"call not properly authenticated"}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"sid":"10"}`))
+	}))
+	return server, &logins
+}
+
+func buildLoginClient(server *httptest.Server, password string) *SplunkClient {
+	hostAndPort := strings.TrimPrefix(server.URL, "https://")
+	return NewClientAuthenticatedByLogin(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(hostAndPort, ":")[0],
+		strings.Split(hostAndPort, ":")[1],
+		"admin",
+		password,
+		&tls.Config{InsecureSkipVerify: true},
+	)
+}
+
+func TestLoginSessionIsCachedAndRenewed(t *testing.T) {
+	server, logins := buildLoginServer()
+	defer server.Close()
+	client := buildLoginClient(server, "password")
+	endpoint := CreateEndpoint(client, "services/search/v2/jobs/")
+
+	for i := 0; i < 3; i++ {
+		resp, err := MakeHttpRequest(context.Background(), client, http.MethodGet, endpoint, nil, url.Values{})
+		if err != nil {
+			t.Fatalf("Got an error : %s", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200 but got %v", resp.StatusCode)
+		}
+	}
+	if atomic.LoadInt32(logins) != 1 {
+		t.Fatalf("Expected the session key to be reused but logged in %v times", *logins)
+	}
+
+	// expire the session of the client by logging in elsewhere
+	_, err := Login(context.Background(), client)
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodGet, endpoint, nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(logins) != 3 {
+		t.Fatalf("Expected the session to be renewed but got status %v after %v logins", resp.StatusCode, *logins)
+	}
+}
+
+func TestLoginSharedBetweenConcurrentRequests(t *testing.T) {
+	server, logins := buildLoginServer()
+	defer server.Close()
+	client := buildLoginClient(server, "password")
+	endpoint := CreateEndpoint(client, "services/search/v2/jobs/")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := MakeHttpRequest(context.Background(), client, http.MethodGet, endpoint, nil, url.Values{})
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if atomic.LoadInt32(logins) != 1 {
+		t.Fatalf("Expected a single login but got %v", *logins)
+	}
+}
+
+func TestLoginWithWrongCredentials(t *testing.T) {
+	server, _ := buildLoginServer()
+	defer server.Close()
+	client := buildLoginClient(server, "wrong")
+
+	_, err := MakeHttpRequest(context.Background(), client, http.MethodGet, CreateEndpoint(client, "services/search/v2/jobs/"), nil, url.Values{})
+	if err == nil || !strings.Contains(err.Error(), "login") {
+		t.Fatalf("Expected a login error but got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strings"
+)
+
+const (
+	// owner of the objects shared in an app
+	defaultNamespaceOwner = "nobody"
+	defaultNamespaceApp   = "search"
+)
+
+// Namespace is the user and app context of the requests (servicesNS/owner/app/...)
+// an empty namespace sends the requests to the global services/ endpoints
+type Namespace struct {
+	Owner string
+	App   string
+}
+
+type namespaceKey struct{}
+
+// IsEmpty returns true if neither the owner nor the app is set
+func (n Namespace) IsEmpty() bool {
+	return n.Owner == "" && n.App == ""
+}
+
+// WithNamespace returns a copy of ctx whose requests are sent in the given namespace instead of the one of the client
+func WithNamespace(ctx context.Context, namespace Namespace) context.Context {
+	return context.WithValue(ctx, namespaceKey{}, namespace)
+}
+
+// NamespaceOf returns the namespace of the requests sent with ctx, with the default owner and app filled in
+func NamespaceOf(ctx context.Context, client *SplunkClient) Namespace {
+	namespace, ok := ctx.Value(namespaceKey{}).(Namespace)
+	if !ok || namespace.IsEmpty() {
+		namespace = client.Namespace
+	}
+	if namespace.IsEmpty() {
+		return namespace
+	}
+	if namespace.Owner == "" {
+		namespace.Owner = defaultNamespaceOwner
+	}
+	if namespace.App == "" {
+		namespace.App = defaultNamespaceApp
+	}
+	return namespace
+}
+
+// CreateNamespacedEndpoint returns the url of the given global service (e.g. services/saved/searches/) in the namespace of ctx
+func CreateNamespacedEndpoint(ctx context.Context, client *SplunkClient, service string) string {
+	return CreateEndpoint(client, namespacedService(NamespaceOf(ctx, client), service))
+}
+
+// replace the services/ prefix of the service by servicesNS/owner/app/
+func namespacedService(namespace Namespace, service string) string {
+	service = strings.TrimPrefix(service, "/")
+	if namespace.IsEmpty() || !strings.HasPrefix(service, "services/") {
+		return service
+	}
+	return "servicesNS/" + url.PathEscape(namespace.Owner) + "/" + url.PathEscape(namespace.App) + "/" + strings.TrimPrefix(service, "services/")
+}
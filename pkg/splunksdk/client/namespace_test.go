@@ -0,0 +1,36 @@
+package client
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCreateNamespacedEndpoint(t *testing.T) {
+	client := &SplunkClient{Host: "localhost", Port: "8089"}
+
+	tests := []struct {
+		clientNamespace  Namespace
+		contextNamespace *Namespace
+		service          string
+		expected         string
+	}{
+		{Namespace{}, nil, "services/search/v2/jobs/", "https://localhost:8089/services/search/v2/jobs/"},
+		{Namespace{Owner: "admin", App: "keptn"}, nil, "services/search/v2/jobs/", "https://localhost:8089/servicesNS/admin/keptn/search/v2/jobs/"},
+		{Namespace{App: "keptn"}, nil, "services/saved/searches/", "https://localhost:8089/servicesNS/nobody/keptn/saved/searches/"},
+		{Namespace{Owner: "admin"}, nil, "services/saved/searches/", "https://localhost:8089/servicesNS/admin/search/saved/searches/"},
+		{Namespace{App: "keptn"}, &Namespace{Owner: "svc", App: "project app"}, "services/saved/searches/", "https://localhost:8089/servicesNS/svc/project%20app/saved/searches/"},
+		{Namespace{App: "keptn"}, &Namespace{}, "services/saved/searches/", "https://localhost:8089/servicesNS/nobody/keptn/saved/searches/"},
+		// links returned by splunk are already namespaced
+		{Namespace{App: "keptn"}, nil, "/servicesNS/nobody/search/alerts/fired_alerts/x", "https://localhost:8089/servicesNS/nobody/search/alerts/fired_alerts/x"},
+	}
+	for _, test := range tests {
+		client.Namespace = test.clientNamespace
+		ctx := context.Background()
+		if test.contextNamespace != nil {
+			ctx = WithNamespace(ctx, *test.contextNamespace)
+		}
+		if endpoint := CreateNamespacedEndpoint(ctx, client, test.service); endpoint != test.expected {
+			t.Fatalf("Expected %v but got %v", test.expected, endpoint)
+		}
+	}
+}
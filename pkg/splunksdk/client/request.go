@@ -1,9 +1,11 @@
 package client
 
 import (
+	"context"
 	"encoding/base64"
-	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
@@ -15,6 +17,8 @@ import (
 //		1.  HTTP Authorization tokens : a seesion key
 //		2.  Splunk Authentication tokens
 //		3.  Basic Authentication
+//
+// the session keys of clients authenticated by login are handled by MakeHttpRequest
 func CreateAuthenticationKey(client *SplunkClient) (string, error) {
 
 	switch {
@@ -43,49 +47,107 @@ func CreateAuthenticationKey(client *SplunkClient) (string, error) {
 	return "", fmt.Errorf("no authentication method provided")
 }
 
-// return the message of the error when got http error
-func HandleHttpError(body []byte) (string, error) {
+// return the url of the given splunk REST service (e.g. services/search/v2/jobs/)
+// the service is relative to the BaseURL of the client, or to https://host:port if there is none
+func CreateEndpoint(client *SplunkClient, service string) string {
+	service = strings.TrimPrefix(strings.ReplaceAll(service, " ", ""), "/")
 
-	var bodyJson map[string][]map[string]string
-	errUmarshall := json.Unmarshal([]byte(body), &bodyJson)
-	if errUmarshall != nil {
-		return "", errUmarshall
+	if client.BaseURL != "" {
+		return strings.TrimSuffix(client.BaseURL, "/") + "/" + service
 	}
 
-	if len(bodyJson) > 1 {
-		return bodyJson["messages"][0]["text"], nil
+	scheme := "https"
+	host := strings.ReplaceAll(client.Host, " ", "")
+	switch {
+	case strings.HasPrefix(host, "https://"):
+		host = strings.TrimPrefix(host, "https://")
+	case strings.HasPrefix(host, "http://"):
+		scheme = "http"
+		host = strings.TrimPrefix(host, "http://")
 	}
-	return "", fmt.Errorf("incorrect format")
-}
+	host = strings.TrimSuffix(host, "/")
 
-// MakeHttpRequest creates a new http request - depending on the method (GET, POST, DELETE,...) - and returns the response
-func MakeHttpRequest(client *SplunkClient, method string, spRequestHeaders map[string]string, params url.Values) (*http.Response, error) {
+	return scheme + "://" + net.JoinHostPort(host, client.Port) + "/" + service
+}
 
-	// create a new request
-	req, err := http.NewRequest(method, client.Endpoint, strings.NewReader(params.Encode()))
-	if err != nil {
-		return nil, err
+// return the value of the Authorization header of the requests sent by the client
+// for clients authenticated by login, a new session is created if the current one is staleHeader
+func authorizationHeader(ctx context.Context, client *SplunkClient, staleHeader string) (string, error) {
+	if !client.LoginAuth {
+		return CreateAuthenticationKey(client)
 	}
-	// add the headers
-	if spRequestHeaders == nil {
-		spRequestHeaders = map[string]string{}
+	key, err := getSessionKey(ctx, client, strings.TrimPrefix(staleHeader, "Splunk "))
+	if err != nil {
+		return "", err
 	}
+	return "Splunk " + key, nil
+}
+
+// MakeHttpRequest creates a new http request - depending on the method (GET, POST, DELETE,...) - and returns the response
+// the request is aborted as soon as ctx is cancelled or its deadline is exceeded
+// transient failures are retried according to the retry policy of the client
+func MakeHttpRequest(ctx context.Context, client *SplunkClient, method string, endpoint string, spRequestHeaders map[string]string, params url.Values) (*http.Response, error) {
 
-	token, err := CreateAuthenticationKey(client)
+	token, err := authorizationHeader(ctx, client, "")
 	if err != nil {
 		return nil, err
 	}
-	spRequestHeaders["Authorization"] = token
+	// an expired session key is renewed once per request
+	reauthenticated := false
 
-	for header, val := range spRequestHeaders {
-		req.Header.Add(header, val)
+	policy := client.RetryPolicy
+	if policy == nil {
+		policy = &RetryPolicy{MaxAttempts: 1}
 	}
-	// get the response
-	resp, err := client.Client.Do(req)
+	idempotent := isIdempotent(ctx, method)
+	body := params.Encode()
 
-	if err != nil {
-		return nil, err
-	}
+	for attempt := 1; ; attempt++ {
+		// create a new request
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		// add the headers
+		for header, val := range spRequestHeaders {
+			req.Header.Add(header, val)
+		}
+		req.Header.Set("Authorization", token)
+
+		// get the response
+		resp, err := client.Client.Do(req)
+
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && client.LoginAuth && !reauthenticated {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+
+			reauthenticated = true
+			token, err = authorizationHeader(ctx, client, token)
+			if err != nil {
+				return nil, err
+			}
+			// the renewal does not count as an attempt
+			attempt--
+			continue
+		}
+
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(ctx, idempotent, resp, err) {
+			if err != nil {
+				return nil, err
+			}
+			return resp, nil
+		}
 
-	return resp, nil
+		delay := policy.backoff(attempt)
+		if resp != nil {
+			if wait := retryAfter(resp); wait > 0 {
+				delay = wait
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
 }
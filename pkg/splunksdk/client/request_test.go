@@ -0,0 +1,216 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// build a test server answering with the given status codes, one per request, then with 200
+func buildFlakyServer(statusCodes []int, headers map[string]string) (*httptest.Server, *int32) {
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		call := int(atomic.AddInt32(&calls, 1)) - 1
+		for header, val := range headers {
+			w.Header().Set(header, val)
+		}
+		if call < len(statusCodes) {
+			w.WriteHeader(statusCodes[call])
+			_, _ = w.Write([]byte(`{"messages":[{"type":"ERROR","text":"busy"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"sid":"10"}`))
+	}))
+	return server, &calls
+}
+
+func buildTestClient(server *httptest.Server, policy *RetryPolicy) *SplunkClient {
+	hostAndPort := strings.TrimPrefix(server.URL, "https://")
+	client := NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(hostAndPort, ":")[0],
+		strings.Split(hostAndPort, ":")[1],
+		"token",
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	client.RetryPolicy = policy
+	return client
+}
+
+func testRetryPolicy() *RetryPolicy {
+	policy := DefaultRetryPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 5 * time.Millisecond
+	return policy
+}
+
+func TestMakeHttpRequestRetriesTransientErrors(t *testing.T) {
+	server, calls := buildFlakyServer([]int{http.StatusServiceUnavailable, http.StatusBadGateway}, nil)
+	defer server.Close()
+	client := buildTestClient(server, testRetryPolicy())
+
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodGet, CreateEndpoint(client, "services/search/v2/jobs/"), nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(calls) != 3 {
+		t.Fatalf("Expected a successful third attempt but got status %v after %v attempts", resp.StatusCode, *calls)
+	}
+}
+
+func TestMakeHttpRequestStopsAfterMaxAttempts(t *testing.T) {
+	server, calls := buildFlakyServer([]int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable}, nil)
+	defer server.Close()
+	client := buildTestClient(server, testRetryPolicy())
+
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodGet, CreateEndpoint(client, "services/search/v2/jobs/"), nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable || atomic.LoadInt32(calls) != 3 {
+		t.Fatalf("Expected the last error after 3 attempts but got status %v after %v attempts", resp.StatusCode, *calls)
+	}
+}
+
+func TestMakeHttpRequestDoesNotRetryNonIdempotentPost(t *testing.T) {
+	server, calls := buildFlakyServer([]int{http.StatusBadGateway}, nil)
+	defer server.Close()
+	client := buildTestClient(server, testRetryPolicy())
+
+	// the saved search may have been created, sending it again would duplicate it
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodPost, CreateEndpoint(client, "services/saved/searches/"), nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway || atomic.LoadInt32(calls) != 1 {
+		t.Fatalf("Expected no retry but got status %v after %v attempts", resp.StatusCode, *calls)
+	}
+
+	// the same request marked as idempotent is retried
+	resp, err = MakeHttpRequest(WithIdempotentRequest(context.Background()), client, http.MethodPost, CreateEndpoint(client, "services/search/v2/jobs/"), nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected an idempotent request to be retried but got status %v", resp.StatusCode)
+	}
+}
+
+func TestMakeHttpRequestDoesNotRetryNonIdempotentPostOnServiceUnavailable(t *testing.T) {
+	server, calls := buildFlakyServer([]int{http.StatusServiceUnavailable}, nil)
+	defer server.Close()
+	client := buildTestClient(server, testRetryPolicy())
+
+	// a 503 may come from a proxy which already forwarded the request, the job may be running
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodPost, CreateEndpoint(client, "services/search/v2/jobs/"), nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || atomic.LoadInt32(calls) != 1 {
+		t.Fatalf("Expected no retry but got status %v after %v attempts", resp.StatusCode, *calls)
+	}
+}
+
+func TestMakeHttpRequestRetriesRejectedPost(t *testing.T) {
+	server, calls := buildFlakyServer([]int{http.StatusTooManyRequests}, nil)
+	defer server.Close()
+	client := buildTestClient(server, testRetryPolicy())
+
+	// a 429 means that splunk did not process the request
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodPost, CreateEndpoint(client, "services/saved/searches/"), nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(calls) != 2 {
+		t.Fatalf("Expected a successful second attempt but got status %v after %v attempts", resp.StatusCode, *calls)
+	}
+}
+
+func TestMakeHttpRequestHonorsRetryAfter(t *testing.T) {
+	server, _ := buildFlakyServer([]int{http.StatusTooManyRequests}, map[string]string{"Retry-After": "1"})
+	defer server.Close()
+	client := buildTestClient(server, testRetryPolicy())
+
+	start := time.Now()
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodGet, CreateEndpoint(client, "services/search/v2/jobs/"), nil, url.Values{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	defer resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("Expected to wait for the Retry-After delay but retried after %v", elapsed)
+	}
+}
+
+func TestMakeHttpRequestRetryCancelledByContext(t *testing.T) {
+	server, calls := buildFlakyServer([]int{http.StatusServiceUnavailable}, map[string]string{"Retry-After": "30"})
+	defer server.Close()
+	client := buildTestClient(server, testRetryPolicy())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := MakeHttpRequest(ctx, client, http.MethodGet, CreateEndpoint(client, "services/search/v2/jobs/"), nil, url.Values{})
+	if err == nil || atomic.LoadInt32(calls) != 1 {
+		t.Fatalf("Expected the retry to be cancelled but got %v after %v attempts", err, *calls)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+
+	expectedDelays := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+	for i, expected := range expectedDelays {
+		delay := policy.backoff(i + 1)
+		if delay < expected/2 || delay > expected*3/2 {
+			t.Fatalf("Retry %v : expected a delay around %v but got %v", i+1, expected, delay)
+		}
+	}
+}
+
+func TestCreateEndpoint(t *testing.T) {
+	tests := []struct {
+		client   *SplunkClient
+		expected string
+	}{
+		{&SplunkClient{Host: "localhost", Port: "8089"}, "https://localhost:8089/services/search/v2/jobs/"},
+		{&SplunkClient{Host: "https://localhost", Port: "8089"}, "https://localhost:8089/services/search/v2/jobs/"},
+		{&SplunkClient{Host: "http://localhost", Port: "8089"}, "http://localhost:8089/services/search/v2/jobs/"},
+		{&SplunkClient{BaseURL: "https://gateway/splunk-api/"}, "https://gateway/splunk-api/services/search/v2/jobs/"},
+		{&SplunkClient{BaseURL: "http://localhost:8089", Host: "ignored", Port: "1"}, "http://localhost:8089/services/search/v2/jobs/"},
+	}
+	for _, test := range tests {
+		if endpoint := CreateEndpoint(test.client, "services/search/v2/jobs/"); endpoint != test.expected {
+			t.Fatalf("Expected %v but got %v", test.expected, endpoint)
+		}
+	}
+
+	// links returned by splunk are absolute paths
+	client := &SplunkClient{BaseURL: "https://gateway/splunk-api"}
+	if endpoint := CreateEndpoint(client, "/servicesNS/nobody/search/search/jobs/10/results"); endpoint != "https://gateway/splunk-api/servicesNS/nobody/search/search/jobs/10/results" {
+		t.Fatalf("Expected the link to be relative to the base url but got %v", endpoint)
+	}
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy describes how requests failing with a transient error are retried
+type RetryPolicy struct {
+	// maximum number of attempts, the first one included. 1 disables retries
+	MaxAttempts int
+	// delay before the first retry
+	InitialBackoff time.Duration
+	// upper bound of the delay between two attempts
+	MaxBackoff time.Duration
+	// factor applied to the delay after each attempt
+	Multiplier float64
+	// fraction (between 0 and 1) of the delay which is randomized
+	Jitter float64
+	// http status codes considered as transient
+	RetryableStatusCodes []int
+}
+
+type idempotentRequestKey struct{}
+
+// return the retry policy used by default by the clients
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       500 * time.Millisecond,
+		MaxBackoff:           10 * time.Second,
+		Multiplier:           2,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// WithIdempotentRequest marks the requests made with the returned context as safe to be sent several times,
+// even if their http method is not idempotent (e.g. a POST setting the acl of an alert)
+func WithIdempotentRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentRequestKey{}, true)
+}
+
+// check if a request can be sent again without side effects
+func isIdempotent(ctx context.Context, method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	idempotent, _ := ctx.Value(idempotentRequestKey{}).(bool)
+	return idempotent
+}
+
+// check if the request has to be sent again given the result of the last attempt
+// non idempotent requests are only retried if splunk has certainly not processed them : when the connection could
+// not be established or when the request was rate limited. A 503 may come from a proxy after splunk got the request
+func (policy *RetryPolicy) shouldRetry(ctx context.Context, idempotent bool, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if err != nil {
+		var opErr *net.OpError
+		return idempotent || (errors.As(err, &opErr) && opErr.Op == "dial")
+	}
+
+	for _, code := range policy.RetryableStatusCodes {
+		if resp.StatusCode == code {
+			return idempotent || code == http.StatusTooManyRequests
+		}
+	}
+	return false
+}
+
+// return the delay to wait before the given retry (starting at 1)
+func (policy *RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(policy.InitialBackoff) * math.Pow(policy.Multiplier, float64(retry-1))
+	if policy.MaxBackoff > 0 && delay > float64(policy.MaxBackoff) {
+		delay = float64(policy.MaxBackoff)
+	}
+	if policy.Jitter > 0 {
+		delay += delay * policy.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(delay)
+}
+
+// return the delay requested by splunk through the Retry-After header, 0 if there is none
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// wait for the given delay, returns an error if ctx is done before
+func sleep(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSOptions describes how the connection to splunk is secured
+type TLSOptions struct {
+	// if true, the certificate of splunk is not verified. Should only be used for testing purposes
+	InsecureSkipVerify bool
+	// path of a PEM bundle of certificate authorities trusted in addition to the system ones
+	CABundlePath string
+	// paths of the PEM certificate and key presented to splunk for mutual TLS
+	ClientCertPath string
+	ClientKeyPath  string
+	// name used to verify the certificate of splunk instead of the host
+	ServerName string
+	// minimum TLS version accepted : 1.0, 1.1, 1.2 or 1.3
+	MinVersion string
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// NewTLSConfig creates the tls configuration described by the given options
+func NewTLSConfig(options TLSOptions) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: options.InsecureSkipVerify,
+		ServerName:         options.ServerName,
+		MinVersion:         tls.VersionTLS12,
+	}
+
+	if options.MinVersion != "" {
+		version, ok := tlsVersions[options.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported minimum TLS version %s, should be one of 1.0, 1.1, 1.2 or 1.3", options.MinVersion)
+		}
+		tlsConfig.MinVersion = version
+	}
+
+	if options.CABundlePath != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		bundle, err := os.ReadFile(options.CABundlePath)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the CA bundle : %w", err)
+		}
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, fmt.Errorf("no certificate found in the CA bundle %s", options.CABundlePath)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	switch {
+	case options.ClientCertPath != "" && options.ClientKeyPath != "":
+		certificate, err := tls.LoadX509KeyPair(options.ClientCertPath, options.ClientKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("could not load the client certificate : %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	case options.ClientCertPath != "" || options.ClientKeyPath != "":
+		return nil, fmt.Errorf("both the client certificate and its key have to be provided for mutual TLS")
+	}
+
+	return tlsConfig, nil
+}
+
+// use the given tls configuration for the connections of the http client
+// if tlsConfig is nil, the certificate of splunk is verified against the system certificate authorities
+func setTLSConfig(client *http.Client, tlsConfig *tls.Config) {
+	if tlsConfig == nil {
+		return
+	}
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = tlsConfig
+	client.Transport = tr
+}
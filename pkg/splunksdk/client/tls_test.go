@@ -0,0 +1,176 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// write the certificate of the test server in a CA bundle
+func writeCABundle(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	bundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(path, bundle, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// write a self-signed client certificate and its key
+func writeClientCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "splunk-sli-provider"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func buildTLSClient(server *httptest.Server, tlsConfig *tls.Config) *SplunkClient {
+	hostAndPort := strings.TrimPrefix(server.URL, "https://")
+	client := NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		strings.Split(hostAndPort, ":")[0],
+		strings.Split(hostAndPort, ":")[1],
+		"token",
+		tlsConfig,
+	)
+	client.RetryPolicy.MaxAttempts = 1
+	return client
+}
+
+func sendTestRequest(client *SplunkClient) error {
+	resp, err := MakeHttpRequest(context.Background(), client, http.MethodGet, CreateEndpoint(client, "services/search/v2/jobs/"), nil, url.Values{})
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestTLSVerificationWithCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"sid":"10"}`))
+	}))
+	defer server.Close()
+
+	// the certificate of the test server is not signed by a system certificate authority
+	tlsConfig, err := NewTLSConfig(TLSOptions{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if err := sendTestRequest(buildTLSClient(server, tlsConfig)); err == nil {
+		t.Fatalf("Expected the certificate of the server to be rejected")
+	}
+
+	tlsConfig, err = NewTLSConfig(TLSOptions{CABundlePath: writeCABundle(t, server)})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if err := sendTestRequest(buildTLSClient(server, tlsConfig)); err != nil {
+		t.Fatalf("Expected the certificate of the server to be trusted but got : %s", err)
+	}
+
+	// the certificate of the test server is not valid for this name
+	tlsConfig, err = NewTLSConfig(TLSOptions{CABundlePath: writeCABundle(t, server), ServerName: "splunk.local"})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if err := sendTestRequest(buildTLSClient(server, tlsConfig)); err == nil {
+		t.Fatalf("Expected the server name to be verified")
+	}
+}
+
+func TestMutualTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"sid":"10"}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	tlsConfig, err := NewTLSConfig(TLSOptions{CABundlePath: writeCABundle(t, server)})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if err := sendTestRequest(buildTLSClient(server, tlsConfig)); err == nil {
+		t.Fatalf("Expected the request without client certificate to be rejected")
+	}
+
+	certPath, keyPath := writeClientCertificate(t)
+	tlsConfig, err = NewTLSConfig(TLSOptions{CABundlePath: writeCABundle(t, server), ClientCertPath: certPath, ClientKeyPath: keyPath})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if err := sendTestRequest(buildTLSClient(server, tlsConfig)); err != nil {
+		t.Fatalf("Expected the client certificate to be accepted but got : %s", err)
+	}
+}
+
+func TestNewTLSConfigErrors(t *testing.T) {
+	certPath, keyPath := writeClientCertificate(t)
+	otherCertPath, _ := writeClientCertificate(t)
+
+	tests := []struct {
+		name    string
+		options TLSOptions
+	}{
+		{name: "unknown TLS version", options: TLSOptions{MinVersion: "2.0"}},
+		{name: "missing CA bundle", options: TLSOptions{CABundlePath: filepath.Join(t.TempDir(), "missing.pem")}},
+		{name: "CA bundle without certificate", options: TLSOptions{CABundlePath: keyPath}},
+		{name: "client certificate without key", options: TLSOptions{ClientCertPath: certPath}},
+		{name: "mismatched client key", options: TLSOptions{ClientCertPath: otherCertPath, ClientKeyPath: keyPath}},
+	}
+	for _, test := range tests {
+		if _, err := NewTLSConfig(test.options); err == nil {
+			t.Fatalf("%s : expected an error", test.name)
+		}
+	}
+
+	tlsConfig, err := NewTLSConfig(TLSOptions{})
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 || tlsConfig.InsecureSkipVerify {
+		t.Fatalf("Expected certificates to be verified with TLS 1.2 at least by default")
+	}
+}
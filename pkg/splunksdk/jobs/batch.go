@@ -0,0 +1,128 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
+)
+
+// BatchSearch is one of the searches run together in a single job, returning a single result
+type BatchSearch struct {
+	// splunk search in spl syntax
+	Query string
+	// name of the field of the result holding the metric
+	// if empty, the result must have a single field whose name does not start with an underscore
+	ResultField string
+}
+
+// BatchQuery returns the search running the searches in a single job : the result of each search is appended as columns
+// to the one of the first search by appendcols, with its fields prefixed by batch<index>_
+// the subsearches inherit the time range of the job, and are bound by the subsearch limits of splunk (limits.conf, by default
+// 60 seconds and 50000 results), each of them running its base search again
+func BatchQuery(searches []BatchSearch) string {
+	var sb strings.Builder
+	for i, search := range searches {
+		query := strings.TrimSpace(search.Query) + " | rename * as " + batchFieldPrefix(i) + "*"
+		if i == 0 {
+			sb.WriteString(query)
+			continue
+		}
+		// the subsearches have to start with a command
+		if !strings.HasPrefix(query, "|") && !strings.HasPrefix(query, "search ") {
+			query = "search " + query
+		}
+		sb.WriteString(" | appendcols [ " + query + " ]")
+	}
+	return sb.String()
+}
+
+// GetMetricsFromNewBatchJob runs the searches in a single new job over the time range of the request, and returns the metric of each search
+// or the error getting it, wrapping ErrNoData when the search had no result, and the last status of the job
+// the result field and the reducer of the request are ignored. An error is returned when the job failed, when it or one of its
+// subsearches was finalized or truncated by the limits of splunk, or when its results could not be split by search,
+// the searches should then be run separately
+func GetMetricsFromNewBatchJob(ctx context.Context, client *splunk.SplunkClient, spRequest *SearchRequest, searches []BatchSearch) ([]float64, []error, *JobStatus, error) {
+
+	batchRequest := *spRequest
+	batchRequest.Params.SearchQuery = BatchQuery(searches)
+	batchRequest.ResultField = ""
+	batchRequest.Reducer = ""
+
+	sid, err := CreateJob(ctx, client, &batchRequest, jobsPathv2)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("error while creating the job : %w", err)
+	}
+
+	status, err := WaitForJob(ctx, client, sid, batchRequest.Timeout)
+	if status == nil {
+		status = &JobStatus{Sid: sid}
+	}
+	if err != nil {
+		return nil, nil, status, fmt.Errorf("error while waiting for the job : %w", err)
+	}
+
+	// a subsearch stopped by its limits returns partial results without failing the job
+	if incomplete := incompleteBatchMessages(status); len(incomplete) > 0 {
+		return nil, nil, status, fmt.Errorf("the results of the batched searches may be partial : %s", strings.Join(incomplete, " ; "))
+	}
+
+	res, err := RetrieveJobResult(ctx, client, sid)
+	if err != nil {
+		return nil, nil, status, fmt.Errorf("error while handling the results. Error message : %w", err)
+	}
+	if len(res) > 1 {
+		return nil, nil, status, fmt.Errorf("%d results found instead of one, the results of the batched searches cannot be split", len(res))
+	}
+
+	metrics := make([]float64, len(searches))
+	errs := make([]error, len(searches))
+	for i, search := range searches {
+		result := map[string]string{}
+		if len(res) == 1 {
+			result = splitBatchResult(res[0], i)
+		}
+		if len(result) == 0 {
+			metrics[i], errs[i] = -1, fmt.Errorf("result is not a metric. Error message : %w", ErrNoData)
+			continue
+		}
+		metrics[i], err = metricFromResult(result, search.ResultField)
+		if err != nil {
+			metrics[i], errs[i] = -1, fmt.Errorf("result is not a metric. Error message : %w", err)
+		}
+	}
+	return metrics, errs, status, nil
+}
+
+// return the messages telling that the batch job or one of its subsearches did not search all the events,
+// e.g. "[subsearch]: Search auto-finalized after time limit (60 seconds) reached."
+func incompleteBatchMessages(status *JobStatus) []string {
+	var messages []string
+	if status.IsFinalized {
+		messages = append(messages, "the search was finalized before its completion")
+	}
+	for _, message := range status.Messages {
+		if strings.Contains(strings.ToLower(message.Text), "subsearch") {
+			messages = append(messages, message.Type+" "+message.Text)
+		}
+	}
+	return messages
+}
+
+// return the fields of the batch result belonging to the search of the given index, without their prefix
+func splitBatchResult(result map[string]string, index int) map[string]string {
+	prefix := batchFieldPrefix(index)
+	fields := map[string]string{}
+	for field, value := range result {
+		if strings.HasPrefix(field, prefix) {
+			fields[strings.TrimPrefix(field, prefix)] = value
+		}
+	}
+	return fields
+}
+
+func batchFieldPrefix(index int) string {
+	return "batch" + strconv.Itoa(index) + "_"
+}
@@ -0,0 +1,91 @@
+package jobs
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
+	splunkTest "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/pkg/utils"
+)
+
+func TestBatchQuery(t *testing.T) {
+	query := BatchQuery([]BatchSearch{
+		{Query: "index=main error | stats count"},
+		{Query: "index=main | stats avg(duration) as avg, count", ResultField: "avg"},
+		{Query: "| tstats count where index=main"},
+	})
+	expected := `index=main error | stats count | rename * as batch0_*` +
+		` | appendcols [ search index=main | stats avg(duration) as avg, count | rename * as batch1_* ]` +
+		` | appendcols [ | tstats count where index=main | rename * as batch2_* ]`
+	if query != expected {
+		t.Fatalf("Expected %s but got %s", expected, query)
+	}
+}
+
+func TestGetMetricsFromNewBatchJob(t *testing.T) {
+
+	results := `{"results":[{"batch0_count":"12","batch1_avg":"1.5","batch1_count":"12","batch2_count":"null"}]}`
+	jobStatus := splunkTest.DoneJobStatus
+	mux := http.NewServeMux()
+	mux.HandleFunc("/services/search/v2/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"sid":"1689673231.191"}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(results))
+		default:
+			_, _ = w.Write([]byte(jobStatus))
+		}
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	searches := []BatchSearch{
+		{Query: "index=main | stats count"},
+		{Query: "index=main | stats avg(duration) as avg, count", ResultField: "avg"},
+		{Query: "index=main | stats count"},
+		{Query: "index=main | stats dc(host)"},
+	}
+	metrics, errs, status, err := GetMetricsFromNewBatchJob(context.Background(), client, &SearchRequest{}, searches)
+	if err != nil || status.Sid != "1689673231.191" {
+		t.Fatalf("Expected the batch job to succeed but got %v, %+v", err, status)
+	}
+	if metrics[0] != 12 || errs[0] != nil || metrics[1] != 1.5 || errs[1] != nil {
+		t.Fatalf("Expected the metric of each search but got %v, %v", metrics, errs)
+	}
+	// a null value and a search without result
+	if !errors.Is(errs[2], ErrNoData) || !errors.Is(errs[3], ErrNoData) {
+		t.Fatalf("Expected no data for the last searches but got %v", errs)
+	}
+
+	results = `{"results":[{"batch0_count":"12"},{"batch0_count":"13"}]}`
+	_, _, _, err = GetMetricsFromNewBatchJob(context.Background(), client, &SearchRequest{}, searches)
+	if err == nil || !strings.Contains(err.Error(), "cannot be split") {
+		t.Fatalf("Expected an error for several results but got %v", err)
+	}
+
+	// the values of a subsearch stopped by its time limit are partial
+	results = `{"results":[{"batch0_count":"12","batch1_avg":"1.5","batch1_count":"12"}]}`
+	jobStatus = `{"entry":[{"content":{"dispatchState":"DONE","isDone":true,"isFailed":false,
+		"messages":[{"type":"INFO","text":"[subsearch]: Search auto-finalized after time limit (60 seconds) reached."}]}}]}`
+	_, _, _, err = GetMetricsFromNewBatchJob(context.Background(), client, &SearchRequest{}, searches)
+	if err == nil || !strings.Contains(err.Error(), "may be partial") || !strings.Contains(err.Error(), "auto-finalized") {
+		t.Fatalf("Expected an error for a finalized subsearch but got %v", err)
+	}
+}
@@ -1,30 +1,44 @@
 package jobs
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
-	utils "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/pkg/utils"
 )
 
 const resutltUri = "results"
 const jobsPathv2 = "services/search/v2/jobs/"
 
+// ErrNoData is wrapped in the error returned when the search has no result to get the metric from
+var ErrNoData = errors.New("the search returned no data")
+
 type SearchRequest struct {
 	Headers map[string]string
 	Params  SearchParams
+	// maximum duration of the search job, it is cancelled when exceeded. 0 for no limit other than the deadline of the context
+	Timeout time.Duration
+	// name of the field of the result holding the metric
+	// if empty, the result must have a single field whose name does not start with an underscore
+	ResultField string
+	// reducer collapsing the metrics of several results into one : sum, avg, min, max, first, last or a percentile pN
+	// if empty, the search must return a single result
+	Reducer string
 }
 
 type SearchParams struct {
 	// splunk search in spl syntax
 	SearchQuery string
 	OutputMode  string `default:"json"`
-	// splunk returns a job SID only if the job is complete
-	ExecMode string `default:"blocking"`
+	// normal : splunk returns the SID as soon as the job is created, blocking : only once the job is complete
+	ExecMode string `default:"normal"`
 	// earliest (inclusive) time bounds for the search
 	EarliestTime string
 	// latest (exclusive) time bounds for the search
@@ -32,45 +46,151 @@ type SearchParams struct {
 }
 
 // Return a metric from a new created job
-func GetMetricFromNewJob(client *splunk.SplunkClient, spRequest *SearchRequest) (float64, error) {
+func GetMetricFromNewJob(ctx context.Context, client *splunk.SplunkClient, spRequest *SearchRequest) (float64, error) {
+	metric, _, err := GetMetricAndStatusFromNewJob(ctx, client, spRequest)
+	return metric, err
+}
+
+// GetMetricAndStatusFromNewJob returns a metric from a new created job, and the last status of the job for its diagnostics
+// the status is nil if the job could not be created, it is returned even when the metric could not be retrieved
+func GetMetricAndStatusFromNewJob(ctx context.Context, client *splunk.SplunkClient, spRequest *SearchRequest) (float64, *JobStatus, error) {
+
+	err := ValidateReducer(spRequest.Reducer)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	sid, err := CreateJob(ctx, client, spRequest, jobsPathv2)
+	if err != nil {
+		return -1, nil, fmt.Errorf("error while creating the job : %w", err)
+	}
+
+	return getMetricFromJob(ctx, client, sid, spRequest)
+}
+
+// wait for the job get by its SID and return the metric of its results, and its last status
+func getMetricFromJob(ctx context.Context, client *splunk.SplunkClient, sid string, spRequest *SearchRequest) (float64, *JobStatus, error) {
 
-	sid, err := CreateJob(client, spRequest, jobsPathv2)
+	status, err := WaitForJob(ctx, client, sid, spRequest.Timeout)
+	if status == nil {
+		status = &JobStatus{Sid: sid}
+	}
 	if err != nil {
-		return -1, fmt.Errorf("error while creating the job : %w", err)
+		return -1, status, fmt.Errorf("error while waiting for the job : %w", err)
 	}
 
-	res, err := RetrieveJobResult(client, sid)
+	res, err := RetrieveJobResult(ctx, client, sid)
 
 	if err != nil {
-		return -1, fmt.Errorf("error while handling the results. Error message : %w", err)
+		return -1, status, fmt.Errorf("error while handling the results. Error message : %w", err)
+	}
+	if spRequest.Reducer != "" {
+		metric, err := reduceResults(res, spRequest.ResultField, spRequest.Reducer)
+		return metric, status, err
 	}
+
 	// if the result is not a metric
+	if len(res) == 0 {
+		return -1, status, fmt.Errorf("result is not a metric. Error message : %w", ErrNoData)
+	}
 	if len(res) != 1 {
-		if len(res) == 0 {
-			err = fmt.Errorf("no result found")
+		err = fmt.Errorf("%d results found instead of one, a reducer has to be set to aggregate several results", len(res))
+		return -1, status, fmt.Errorf("result is not a metric. Error message : %w", err)
+	}
+
+	metric, err := metricFromResult(res[0], spRequest.ResultField)
+	if err != nil {
+		return -1, status, fmt.Errorf("result is not a metric. Error message : %w", err)
+	}
+	return metric, status, nil
+}
+
+// return the metric of each result, collapsed by the reducer
+// the results where the field is null are skipped
+func reduceResults(results []map[string]string, field string, reducer string) (float64, error) {
+	values := make([]float64, 0, len(results))
+	for i, result := range results {
+		resultField := field
+		if fields := resultFields(result); resultField == "" && len(fields) == 1 {
+			resultField = fields[0]
+		}
+		if resultField != "" && IsNullValue(result[resultField]) {
+			continue
+		}
+		metric, err := metricFromResult(result, field)
+		if err != nil {
+			return -1, fmt.Errorf("result %d is not a metric. Error message : %w", i, err)
 		}
-		return -1, fmt.Errorf("result is not a metric. Error message : %w", err)
+		values = append(values, metric)
 	}
-	var metrics []string
+	if len(values) == 0 {
+		return -1, fmt.Errorf("no value to reduce in the %d results : %w", len(results), ErrNoData)
+	}
+
+	metric, err := ReduceMetrics(values, reducer)
+	if err != nil {
+		return -1, fmt.Errorf("could not reduce the %d results with %s : %w", len(results), reducer, err)
+	}
+	return metric, nil
+}
 
-	for _, v := range res[0] {
-		metrics = append(metrics, v)
+// return the value of the given field of the result, or of its single field if none is given
+func metricFromResult(result map[string]string, field string) (float64, error) {
+
+	if field == "" {
+		fields := resultFields(result)
+		switch len(fields) {
+		case 0:
+			return -1, fmt.Errorf("no field found in the result")
+		case 1:
+			field = fields[0]
+		default:
+			return -1, fmt.Errorf("the result has several fields (%s), the field holding the metric has to be set", strings.Join(fields, ", "))
+		}
+	}
+
+	value, ok := result[field]
+	if !ok {
+		return -1, fmt.Errorf("field %s not found in the result, available fields : %s", field, strings.Join(resultFields(result), ", "))
+	}
+	if IsNullValue(value) {
+		return -1, fmt.Errorf("value %q of field %s is null : %w", value, field, ErrNoData)
 	}
-	metric, err := strconv.ParseFloat(metrics[0], 64)
+	metric, err := strconv.ParseFloat(value, 64)
 	if err != nil {
-		return -1, fmt.Errorf("convert metric to float failed. Error message : %w", err)
+		return -1, fmt.Errorf("value %q of field %s is not a number", value, field)
 	}
 
 	return metric, nil
 }
 
+// IsNullValue returns whether the value of a field of a result means that there is no data : empty, null or N/A
+func IsNullValue(value string) bool {
+	value = strings.TrimSpace(value)
+	return value == "" || strings.EqualFold(value, "null") || strings.EqualFold(value, "N/A")
+}
+
+// return the sorted names of the fields of the result, apart from the internal ones starting with an underscore
+func resultFields(result map[string]string) []string {
+	fields := make([]string, 0, len(result))
+	for field := range result {
+		if !strings.HasPrefix(field, "_") {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
 // this function create a new job and return its SID
-func CreateJob(client *splunk.SplunkClient, spRequest *SearchRequest, service string) (string, error) {
+func CreateJob(ctx context.Context, client *splunk.SplunkClient, spRequest *SearchRequest, service string) (string, error) {
 
 	// create the endpoint for the request
-	utils.CreateEndpoint(client, jobsPathv2)
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, service)
 
-	resp, err := PostJob(client, spRequest)
+	// a job created twice would run twice, using the concurrent search quota, so the request is only sent again
+	// when splunk has certainly not processed it
+	resp, err := PostJob(ctx, client, endpoint, spRequest)
 
 	if err != nil {
 		return "", fmt.Errorf("error while making the post request : %w", err)
@@ -79,13 +199,7 @@ func CreateJob(client *splunk.SplunkClient, spRequest *SearchRequest, service st
 	body, err := io.ReadAll(resp.Body)
 	// handle error
 	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(body)
-		switch err {
-		case nil:
-			return "", fmt.Errorf("http error :  %s", status)
-		default:
-			return "", fmt.Errorf("http error :  %s", resp.Status)
-		}
+		return "", splunk.NewSplunkError(resp, body)
 	}
 
 	if err != nil {
@@ -103,19 +217,14 @@ func CreateJob(client *splunk.SplunkClient, spRequest *SearchRequest, service st
 }
 
 // return the result of a job get by its SID
-func RetrieveJobResult(client *splunk.SplunkClient, sid string) ([]map[string]string, error) {
-
-	newEndpoint := client.Endpoint + sid
-	// check if the endpoint is correctly formed
-	if !strings.HasSuffix(newEndpoint, "/") {
-		newEndpoint += "/"
-	}
+func RetrieveJobResult(ctx context.Context, client *splunk.SplunkClient, sid string) ([]map[string]string, error) {
 
-	// the endpoint where to find the corresponding job
-	client.Endpoint = newEndpoint + resutltUri
+	// the endpoint where to find the results of the corresponding job
+	// by default, splunk only returns the first 100 results
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, jobsPathv2+sid+"/"+resutltUri) + "?count=0"
 
 	// make the get request
-	getResp, err := GetJob(client)
+	getResp, err := GetJob(ctx, client, endpoint)
 	if err != nil {
 		return nil, fmt.Errorf("error while making the get request : %w", err)
 	}
@@ -124,13 +233,9 @@ func RetrieveJobResult(client *splunk.SplunkClient, sid string) ([]map[string]st
 	getBody, err := io.ReadAll(getResp.Body)
 	// handle error
 	if !strings.HasPrefix(strconv.Itoa(getResp.StatusCode), "2") {
-		status, err := splunk.HandleHttpError(getBody)
-		switch err {
-		case nil:
-			return nil, fmt.Errorf("http error :  %s", status)
-		default:
-			return nil, fmt.Errorf("http error :  %s", getResp.Status)
-		}
+		splunkErr := splunk.NewSplunkError(getResp, getBody)
+		splunkErr.Sid = sid
+		return nil, splunkErr
 	}
 	if err != nil {
 		return nil, fmt.Errorf("error while getting the body of the get request : %w", err)
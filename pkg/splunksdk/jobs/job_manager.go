@@ -1,6 +1,7 @@
 package jobs
 
 import (
+	"context"
 	"net/http"
 	"net/url"
 
@@ -8,31 +9,44 @@ import (
 	utils "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/pkg/utils"
 )
 
-func PostJob(client *splunk.SplunkClient, spRequest *SearchRequest) (*http.Response, error) {
+func PostJob(ctx context.Context, client *splunk.SplunkClient, endpoint string, spRequest *SearchRequest) (*http.Response, error) {
 
-	return HttpJobRequest(client, http.MethodPost, spRequest)
+	return HttpJobRequest(ctx, client, http.MethodPost, endpoint, spRequest)
 }
 
-func GetJob(client *splunk.SplunkClient) (*http.Response, error) {
+func GetJob(ctx context.Context, client *splunk.SplunkClient, endpoint string) (*http.Response, error) {
 
-	return HttpJobRequest(client, http.MethodGet, nil)
+	return HttpJobRequest(ctx, client, http.MethodGet, endpoint, nil)
 }
 
-func HttpJobRequest(client *splunk.SplunkClient, method string, spRequest *SearchRequest) (*http.Response, error) {
+func PostJobControl(ctx context.Context, client *splunk.SplunkClient, endpoint string, action string) (*http.Response, error) {
+
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	params.Add("action", action)
+
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	return splunk.MakeHttpRequest(ctx, client, http.MethodPost, endpoint, headers, params)
+}
+
+func HttpJobRequest(ctx context.Context, client *splunk.SplunkClient, method string, endpoint string, spRequest *SearchRequest) (*http.Response, error) {
 
 	if spRequest == nil {
 		spRequest = &SearchRequest{}
 	}
 
 	spRequest.Params.OutputMode = "json"
-	spRequest.Params.ExecMode = "blocking"
 
 	// parameters of the request
 	params := url.Values{}
 	params.Add("output_mode", spRequest.Params.OutputMode)
-	params.Add("exec_mode", spRequest.Params.ExecMode)
 
 	if method == http.MethodPost {
+		// by default, splunk returns the sid as soon as the job is created
+		if spRequest.Params.ExecMode == "" {
+			spRequest.Params.ExecMode = "normal"
+		}
+		params.Add("exec_mode", spRequest.Params.ExecMode)
 		params.Add("search", utils.ValidateSearchQuery(spRequest.Params.SearchQuery))
 		if spRequest.Params.EarliestTime != "" {
 			params.Add("earliest_time", spRequest.Params.EarliestTime)
@@ -42,5 +56,5 @@ func HttpJobRequest(client *splunk.SplunkClient, method string, spRequest *Searc
 		}
 	}
 
-	return splunk.MakeHttpRequest(client, method, spRequest.Headers, params)
+	return splunk.MakeHttpRequest(ctx, client, method, endpoint, spRequest.Headers, params)
 }
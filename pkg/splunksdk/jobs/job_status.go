@@ -0,0 +1,249 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
+)
+
+const controlUri = "control"
+
+// delays between two polls of the status of a job, doubled after each poll
+const (
+	minPollInterval = 100 * time.Millisecond
+	maxPollInterval = 2 * time.Second
+)
+
+// maximum duration of the cancellation of a job whose deadline is exceeded
+const cancelTimeout = 10 * time.Second
+
+// dispatch states of a search job
+const (
+	DispatchStateDone   = "DONE"
+	DispatchStateFailed = "FAILED"
+)
+
+// JobStatus is the dispatch status of a search job
+type JobStatus struct {
+	Sid           string
+	DispatchState string `json:"dispatchState"`
+	IsDone        bool   `json:"isDone"`
+	IsFailed      bool   `json:"isFailed"`
+	// the job was finalized before its completion, its results may be partial
+	IsFinalized bool `json:"isFinalized"`
+	// number of events scanned by the search and number of its results
+	ScanCount   int `json:"scanCount"`
+	ResultCount int `json:"resultCount"`
+	// duration of the search in seconds
+	RunDuration float64 `json:"runDuration"`
+	// latest time of the events searched, in ISO 8601
+	LatestTime string           `json:"latestTime"`
+	Messages   []splunk.Message `json:"messages"`
+}
+
+// Warnings returns the messages of the job warning that its results may be partial or wrong
+func (s *JobStatus) Warnings() []splunk.Message {
+	var warnings []splunk.Message
+	if s.IsFinalized {
+		warnings = append(warnings, splunk.Message{Type: "WARN", Text: "the search was finalized before its completion, its results may be partial"})
+	}
+	for _, message := range s.Messages {
+		if message.Type == "WARN" || message.Type == "ERROR" {
+			warnings = append(warnings, message)
+		}
+	}
+	return warnings
+}
+
+// JobError is returned when a search job fails or does not complete in time
+type JobError struct {
+	Sid           string
+	DispatchState string
+	Messages      []splunk.Message
+	// the job was cancelled because its deadline was exceeded
+	Cancelled bool
+	// the error which stopped the job, if any
+	Err error
+}
+
+func (e *JobError) Error() string {
+	var sb strings.Builder
+	switch {
+	case e.Cancelled:
+		sb.WriteString("search job " + e.Sid + " did not complete in time and was cancelled")
+	default:
+		sb.WriteString("search job " + e.Sid + " failed")
+	}
+	if e.DispatchState != "" {
+		sb.WriteString(" (state " + e.DispatchState + ")")
+	}
+	for i, message := range e.Messages {
+		if i == 0 {
+			sb.WriteString(" : ")
+		} else {
+			sb.WriteString(" ; ")
+		}
+		sb.WriteString(message.Type + " " + message.Text)
+	}
+	if e.Err != nil {
+		sb.WriteString(" : " + e.Err.Error())
+	}
+	return sb.String()
+}
+
+func (e *JobError) Unwrap() error {
+	return e.Err
+}
+
+// return the status of the job get by its SID
+func GetJobStatus(ctx context.Context, client *splunk.SplunkClient, sid string) (*JobStatus, error) {
+
+	// the endpoint where to find the status of the corresponding job
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, jobsPathv2+sid)
+
+	resp, err := GetJob(ctx, client, endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error while making the get request : %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	// handle error
+	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
+		splunkErr := splunk.NewSplunkError(resp, body)
+		splunkErr.Sid = sid
+		return nil, splunkErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error while getting the body of the get request : %w", err)
+	}
+
+	// only get the content of the job entry
+	var jobEntries struct {
+		Entry []struct {
+			Content JobStatus `json:"content"`
+		} `json:"entry"`
+	}
+	err = json.Unmarshal(body, &jobEntries)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the status of job %s : %w", sid, err)
+	}
+	if len(jobEntries.Entry) == 0 {
+		return nil, fmt.Errorf("no status found for job %s", sid)
+	}
+
+	status := jobEntries.Entry[0].Content
+	status.Sid = sid
+	return &status, nil
+}
+
+// WaitForJob polls the status of the job until it is done and returns its final status
+// if the job is not done within timeout (0 for no limit), it is cancelled and a cancelled JobError is returned
+// if ctx is done first, the job is cancelled too and the error of ctx is returned
+// if its status cannot be polled, the job is cancelled as well and its last known status is returned with the error
+func WaitForJob(ctx context.Context, client *splunk.SplunkClient, sid string, timeout time.Duration) (*JobStatus, error) {
+
+	waitCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	status := &JobStatus{Sid: sid}
+	interval := minPollInterval
+	for {
+		current, err := GetJobStatus(waitCtx, client, sid)
+		switch {
+		case err != nil && waitCtx.Err() == nil:
+			// nobody would wait for the job anymore, it would keep running in splunk
+			if cancelErr := cancelInBackground(ctx, client, sid); cancelErr != nil {
+				err = errors.Join(err, cancelErr)
+			}
+			return status, err
+		case err != nil:
+			// the deadline is exceeded, the job is cancelled below
+		case current.IsFailed || current.DispatchState == DispatchStateFailed:
+			return current, &JobError{Sid: sid, DispatchState: current.DispatchState, Messages: current.Messages}
+		case current.IsDone || current.DispatchState == DispatchStateDone:
+			return current, nil
+		default:
+			status = current
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-waitCtx.Done():
+			timer.Stop()
+			return status, cancelStoppedJob(ctx, client, status, waitCtx.Err())
+		case <-timer.C:
+		}
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
+
+// cancel the job which is no longer waited for and return the corresponding error :
+// the error of ctx if the caller stopped waiting, or a cancelled JobError if the deadline of the job is exceeded
+func cancelStoppedJob(ctx context.Context, client *splunk.SplunkClient, status *JobStatus, cause error) error {
+	parentErr := ctx.Err()
+	err := cancelInBackground(ctx, client, status.Sid)
+
+	if parentErr != nil {
+		parentErr = fmt.Errorf("stopped waiting for search job %s : %w", status.Sid, parentErr)
+		if err != nil {
+			return errors.Join(parentErr, err)
+		}
+		return parentErr
+	}
+
+	jobErr := &JobError{Sid: status.Sid, DispatchState: status.DispatchState, Messages: status.Messages, Cancelled: true, Err: cause}
+	if err != nil {
+		jobErr.Err = errors.Join(cause, err)
+	}
+	return jobErr
+}
+
+// cancel the job even if ctx is done, in the namespace of ctx
+func cancelInBackground(ctx context.Context, client *splunk.SplunkClient, sid string) error {
+
+	// the context of the search may already be done, the cancellation gets its own deadline
+	cancelCtx, cancel := context.WithTimeout(splunk.WithNamespace(context.Background(), splunk.NamespaceOf(ctx, client)), cancelTimeout)
+	defer cancel()
+	return CancelJob(cancelCtx, client, sid)
+}
+
+// CancelJob stops the job get by its SID and deletes its results
+func CancelJob(ctx context.Context, client *splunk.SplunkClient, sid string) error {
+
+	// the endpoint controlling the corresponding job
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, jobsPathv2+sid+"/"+controlUri)
+
+	// cancelling a job twice has no side effect, so the request can be retried
+	resp, err := PostJobControl(splunk.WithIdempotentRequest(ctx), client, endpoint, "cancel")
+	if err != nil {
+		return fmt.Errorf("error while cancelling job %s : %w", sid, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	// handle error
+	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
+		splunkErr := splunk.NewSplunkError(resp, body)
+		splunkErr.Sid = sid
+		return fmt.Errorf("error while cancelling job %s : %w", sid, splunkErr)
+	}
+	if err != nil {
+		return fmt.Errorf("error while getting the body of the cancel request : %w", err)
+	}
+
+	return nil
+}
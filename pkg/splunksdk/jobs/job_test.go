@@ -1,12 +1,20 @@
 package jobs
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/alerts"
 	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
-	"github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/pkg/utils"
 	splunkTest "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/pkg/utils"
 
 	"github.com/joho/godotenv"
@@ -41,7 +49,7 @@ func TestGetMetric(t *testing.T) {
 		splunkTest.GetTestHostname(server),
 		splunkTest.GetTestPort(server),
 		splunkTest.GetTestToken(),
-		true,
+		&tls.Config{InsecureSkipVerify: true},
 	)
 
 	defer server.Close()
@@ -52,7 +60,7 @@ func TestGetMetric(t *testing.T) {
 		},
 	}
 
-	metric, err := GetMetricFromNewJob(client, &spReq)
+	metric, err := GetMetricFromNewJob(context.Background(), client, &spReq)
 
 	if err != nil {
 		t.Fatalf("Got an error : %s", err)
@@ -86,12 +94,10 @@ func TestCreateJob(t *testing.T) {
 		splunkTest.GetTestHostname(server),
 		splunkTest.GetTestPort(server),
 		splunkTest.GetTestToken(),
-		true,
+		&tls.Config{InsecureSkipVerify: true},
 	)
 
-	utils.CreateEndpoint(client, splunkTest.JobsPathv2)
-
-	sid, err := CreateJob(client, &spReq, splunkTest.JobsPathv2)
+	sid, err := CreateJob(context.Background(), client, &spReq, splunkTest.JobsPathv2)
 
 	if err != nil {
 		t.Fatalf("Got an error : %s", err)
@@ -103,6 +109,130 @@ func TestCreateJob(t *testing.T) {
 	}
 }
 
+// Tests that a job whose creation may have been processed by splunk is not created again
+func TestCreateJobNotRetriedOnBadGateway(t *testing.T) {
+
+	var calls int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`{"messages":[{"type":"ERROR","text":"bad gateway"}]}`))
+	}))
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+	client.RetryPolicy.InitialBackoff = time.Millisecond
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | stats count",
+		},
+	}
+	_, err := CreateJob(context.Background(), client, &spReq, splunkTest.JobsPathv2)
+
+	var splunkErr *splunk.SplunkError
+	if !errors.As(err, &splunkErr) || splunkErr.StatusCode != http.StatusBadGateway {
+		t.Fatalf("Expected the bad gateway error but got %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("Expected a single attempt to create the job but got %d", calls)
+	}
+}
+
+func TestGetMetricBehindPathPrefix(t *testing.T) {
+
+	// splunk is reached through a reverse proxy on plain http
+	mux := http.NewServeMux()
+	mux.HandleFunc("/splunk-api/services/search/v2/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"sid":"1689673231.191"}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"2566"}]}`))
+		default:
+			_, _ = w.Write([]byte(splunkTest.DoneJobStatus))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		"",
+		"",
+		splunkTest.GetTestToken(),
+		nil,
+	)
+	client.BaseURL = server.URL + "/splunk-api/"
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | stats count",
+		},
+	}
+
+	metric, err := GetMetricFromNewJob(context.Background(), client, &spReq)
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if metric != 2566 {
+		t.Fatalf("Expected %v but got %v.", 2566, metric)
+	}
+}
+
+func TestGetMetricInNamespace(t *testing.T) {
+
+	// the search is only valid in the app defining its macro
+	mux := http.NewServeMux()
+	mux.HandleFunc("/servicesNS/nobody/keptn/search/v2/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"sid":"1689673231.191"}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"2566"}]}`))
+		default:
+			_, _ = w.Write([]byte(splunkTest.DoneJobStatus))
+		}
+	})
+	server := httptest.NewTLSServer(mux)
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "`keptn_errors` | stats count",
+		},
+	}
+
+	ctx := splunk.WithNamespace(context.Background(), splunk.Namespace{App: "keptn"})
+	metric, err := GetMetricFromNewJob(ctx, client, &spReq)
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if metric != 2566 {
+		t.Fatalf("Expected %v but got %v.", 2566, metric)
+	}
+}
+
 func TestRetrieveJobResult(t *testing.T) {
 
 	_ = godotenv.Load(".env")
@@ -120,10 +250,9 @@ func TestRetrieveJobResult(t *testing.T) {
 		splunkTest.GetTestHostname(server),
 		splunkTest.GetTestPort(server),
 		splunkTest.GetTestToken(),
-		true,
+		&tls.Config{InsecureSkipVerify: true},
 	)
-	utils.CreateEndpoint(client, splunkTest.JobsPathv2)
-	results, err := RetrieveJobResult(client, "1689673231.191")
+	results, err := RetrieveJobResult(context.Background(), client, "1689673231.191")
 
 	if err != nil {
 		t.Fatalf("Got an error : %s", err)
@@ -138,3 +267,462 @@ func TestRetrieveJobResult(t *testing.T) {
 		t.Fatalf("Expected %v but got %v.", expectedRes, results)
 	}
 }
+
+func TestGetMetricCancelledByContext(t *testing.T) {
+
+	// the server never answers before the context deadline
+	release := make(chan struct{})
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | stats count",
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := GetMetricFromNewJob(ctx, client, &spReq)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a context deadline error but got %v", err)
+	}
+}
+
+func TestGetMetricInvalidSearch(t *testing.T) {
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"messages":[{"type":"FATAL","text":"Unknown search command 'stat'."}]}`))
+	}))
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | stat count",
+		},
+	}
+
+	_, err := GetMetricFromNewJob(context.Background(), client, &spReq)
+
+	var splunkErr *splunk.SplunkError
+	if !errors.As(err, &splunkErr) {
+		t.Fatalf("Expected a splunk error but got %v", err)
+	}
+	if splunkErr.Kind() != splunk.ErrorKindInvalidSearch || splunkErr.Messages[0].Text != "Unknown search command 'stat'." {
+		t.Fatalf("Expected an invalid search error but got %v", splunkErr)
+	}
+}
+
+func TestGetMetricJobFailed(t *testing.T) {
+
+	responses := make([]map[string]interface{}, 1)
+	responses[0] = map[string]interface{}{
+		http.MethodPost:         `{"sid":"1689673231.191"}`,
+		splunkTest.GetJobStatus: `{"entry":[{"content":{"dispatchState":"FAILED","isDone":true,"isFailed":true,"messages":[{"type":"FATAL","text":"The lookup table 'hosts' does not exist."}]}}]}`,
+	}
+	server := splunkTest.MultitpleMockRequest(responses, true)
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | lookup hosts host | stats count",
+		},
+	}
+
+	_, err := GetMetricFromNewJob(context.Background(), client, &spReq)
+
+	var jobErr *JobError
+	if !errors.As(err, &jobErr) {
+		t.Fatalf("Expected a job error but got %v", err)
+	}
+	if jobErr.Cancelled || jobErr.Sid != "1689673231.191" || len(jobErr.Messages) != 1 || !strings.Contains(err.Error(), "The lookup table 'hosts' does not exist.") {
+		t.Fatalf("Expected the failure messages of the job but got %v", err)
+	}
+}
+
+// Tests that the diagnostics of the job are returned with the metric
+func TestGetMetricAndStatus(t *testing.T) {
+
+	responses := make([]map[string]interface{}, 2)
+	responses[0] = map[string]interface{}{
+		http.MethodPost: `{"sid":"1689673231.191"}`,
+		splunkTest.GetJobStatus: `{"entry":[{"content":{"dispatchState":"DONE","isDone":true,"isFailed":false,"isFinalized":true,
+			"scanCount":5000,"resultCount":1,"runDuration":1.25,
+			"messages":[{"type":"INFO","text":"Your timerange was substituted."},{"type":"WARN","text":"Search results might be incomplete."}]}}]}`,
+	}
+	responses[1] = map[string]interface{}{
+		http.MethodGet: `{"results":[{"count":"12"}]}`,
+	}
+	server := splunkTest.MultitpleMockRequest(responses, true)
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | stats count",
+		},
+	}
+
+	metric, status, err := GetMetricAndStatusFromNewJob(context.Background(), client, &spReq)
+	if err != nil || metric != 12 {
+		t.Fatalf("Expected the metric but got %v, %v", metric, err)
+	}
+	if status.Sid != "1689673231.191" || status.ScanCount != 5000 || status.ResultCount != 1 || status.RunDuration != 1.25 || len(status.Messages) != 2 {
+		t.Fatalf("Expected the diagnostics of the job but got %+v", status)
+	}
+	warnings := status.Warnings()
+	if len(warnings) != 2 || !strings.Contains(warnings[0].Text, "finalized") || warnings[1].Text != "Search results might be incomplete." {
+		t.Fatalf("Expected the finalization and the warning of the job but got %v", warnings)
+	}
+}
+
+func TestGetMetricJobTimeoutCancelsJob(t *testing.T) {
+
+	var polls, cancelled int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/control"):
+			if r.FormValue("action") == "cancel" {
+				atomic.AddInt32(&cancelled, 1)
+			}
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"sid":"1689673231.191"}`))
+		default:
+			atomic.AddInt32(&polls, 1)
+			_, _ = w.Write([]byte(`{"entry":[{"content":{"dispatchState":"RUNNING","isDone":false,"isFailed":false}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | stats count",
+		},
+		Timeout: 500 * time.Millisecond,
+	}
+
+	_, err := GetMetricFromNewJob(context.Background(), client, &spReq)
+
+	var jobErr *JobError
+	if !errors.As(err, &jobErr) || !jobErr.Cancelled || jobErr.DispatchState != "RUNNING" || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the job to time out but got %v", err)
+	}
+	if atomic.LoadInt32(&polls) < 2 || atomic.LoadInt32(&cancelled) != 1 {
+		t.Fatalf("Expected the job to be polled then cancelled but got %v polls and %v cancellations", polls, cancelled)
+	}
+}
+
+func TestGetMetricCancelledByCallerCancelsJob(t *testing.T) {
+
+	var cancelled int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/control"):
+			if r.FormValue("action") == "cancel" {
+				atomic.AddInt32(&cancelled, 1)
+			}
+		case r.Method == http.MethodPost:
+			_, _ = w.Write([]byte(`{"sid":"1689673231.191"}`))
+		default:
+			_, _ = w.Write([]byte(`{"entry":[{"content":{"dispatchState":"RUNNING","isDone":false,"isFailed":false}}]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | stats count",
+		},
+		Timeout: time.Minute,
+	}
+
+	// the caller gives up long before the deadline of the job
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(300*time.Millisecond, cancel)
+
+	_, err := GetMetricFromNewJob(ctx, client, &spReq)
+
+	var jobErr *JobError
+	if !errors.Is(err, context.Canceled) || errors.As(err, &jobErr) || strings.Contains(err.Error(), "did not complete in time") {
+		t.Fatalf("Expected the error of the context but got %v", err)
+	}
+	if atomic.LoadInt32(&cancelled) != 1 {
+		t.Fatalf("Expected the job to be cancelled but got %v cancellations", cancelled)
+	}
+}
+
+func TestWaitForJobCancelsJobOnPollingError(t *testing.T) {
+
+	var polls, cancelled int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/control"):
+			if r.FormValue("action") == "cancel" {
+				atomic.AddInt32(&cancelled, 1)
+			}
+		case atomic.AddInt32(&polls, 1) == 1:
+			_, _ = w.Write([]byte(`{"entry":[{"content":{"dispatchState":"RUNNING","isDone":false,"isFailed":false}}]}`))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"messages":[{"type":"ERROR","text":"Internal error."}]}`))
+		}
+	}))
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	status, err := WaitForJob(context.Background(), client, "1689673231.191", time.Minute)
+
+	var splunkErr *splunk.SplunkError
+	if !errors.As(err, &splunkErr) || splunkErr.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("Expected the error of the status request but got %v", err)
+	}
+	if status == nil || status.Sid != "1689673231.191" || status.DispatchState != "RUNNING" {
+		t.Fatalf("Expected the last known status of the job but got %+v", status)
+	}
+	if atomic.LoadInt32(&cancelled) != 1 {
+		t.Fatalf("Expected the job to be cancelled but got %v cancellations", cancelled)
+	}
+}
+
+// Tests that a single client can be shared between jobs and alerts requests running in parallel
+// run with -race to detect data races on the client
+func TestConcurrentJobsAndAlerts(t *testing.T) {
+
+	jsonResponsePOST := `{
+		"sid": "1689673231.191"
+	}`
+	jsonResponseGET := `{
+		"results":[{"count":"2566"}]
+	}`
+	jsonResponseTriggeredAlerts := `{
+		"entry":[{"name":"project,stage,service,sli,>0,keptn"}]
+	}`
+
+	responses := make([]map[string]interface{}, 1)
+	responses[0] = map[string]interface{}{
+		splunkTest.GetTriggeredAlerts: jsonResponseTriggeredAlerts,
+		http.MethodPost:               jsonResponsePOST,
+		http.MethodGet:                jsonResponseGET,
+	}
+	server := splunkTest.MultitpleMockRequest(responses, true)
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*workers)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			spReq := SearchRequest{
+				Params: SearchParams{
+					SearchQuery: "index=main | stats count",
+				},
+			}
+			metric, err := GetMetricFromNewJob(context.Background(), client, &spReq)
+			switch {
+			case err != nil:
+				errs <- err
+			case metric != 2566:
+				errs <- fmt.Errorf("expected metric 2566 but got %v", metric)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			triggeredAlerts, err := alerts.GetTriggeredAlerts(context.Background(), client)
+			switch {
+			case err != nil:
+				errs <- err
+			case len(triggeredAlerts.Entry) != 1:
+				errs <- fmt.Errorf("expected one triggered alert but got %v", triggeredAlerts.Entry)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// Tests that the field holding the metric is chosen deterministically
+func TestMetricFromResult(t *testing.T) {
+	tests := []struct {
+		name     string
+		result   map[string]string
+		field    string
+		expected float64
+		err      string
+	}{
+		{"single field", map[string]string{"count": "12"}, "", 12, ""},
+		{"internal fields ignored", map[string]string{"_time": "1689673231", "avg": "3.5"}, "", 3.5, ""},
+		{"named field", map[string]string{"avg": "3.5", "count": "12"}, "count", 12, ""},
+		{"several fields", map[string]string{"count": "12", "avg": "3.5"}, "", -1, "several fields (avg, count)"},
+		{"missing field", map[string]string{"count": "12"}, "avg", -1, "available fields : count"},
+		{"not a number", map[string]string{"host": "web-1"}, "", -1, `value "web-1" of field host is not a number`},
+		{"no field", map[string]string{"_raw": "error"}, "", -1, "no field"},
+		{"empty value", map[string]string{"avg": ""}, "", -1, ErrNoData.Error()},
+		{"null value", map[string]string{"avg": "null"}, "avg", -1, ErrNoData.Error()},
+		{"not available", map[string]string{"avg": "N/A"}, "", -1, ErrNoData.Error()},
+	}
+	for _, test := range tests {
+		metric, err := metricFromResult(test.result, test.field)
+		if test.err == "" && (err != nil || metric != test.expected) {
+			t.Fatalf("%s : expected %v but got %v, %v", test.name, test.expected, metric, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Fatalf("%s : expected an error containing %q but got %v", test.name, test.err, err)
+		}
+	}
+}
+
+// Tests that the results of a timechart are collapsed by the reducer
+func TestGetMetricWithReducer(t *testing.T) {
+
+	jsonResponsePOST := `{
+		"sid": "1689673231.191"
+	}`
+
+	jsonResponseGET := `{
+		"results":[
+			{"_time":"2023-07-18T10:00:00.000+00:00","_span":"60","max(duration)":"12"},
+			{"_time":"2023-07-18T10:01:00.000+00:00","_span":"60","max(duration)":""},
+			{"_time":"2023-07-18T10:02:00.000+00:00","_span":"60","max(duration)":"30"},
+			{"_time":"2023-07-18T10:03:00.000+00:00","_span":"60","max(duration)":"N/A"}
+		]
+	}`
+
+	responses := make([]map[string]interface{}, 2)
+	responses[0] = map[string]interface{}{
+		http.MethodPost: jsonResponsePOST,
+	}
+	responses[1] = map[string]interface{}{
+		http.MethodGet: jsonResponseGET,
+	}
+
+	server := splunkTest.MultitpleMockRequest(responses, true)
+	defer server.Close()
+
+	client := splunk.NewClientAuthenticatedByToken(
+		&http.Client{
+			Timeout: time.Duration(60) * time.Second,
+		},
+		splunkTest.GetTestHostname(server),
+		splunkTest.GetTestPort(server),
+		splunkTest.GetTestToken(),
+		&tls.Config{InsecureSkipVerify: true},
+	)
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			SearchQuery: "index=main | timechart span=1m max(duration)",
+		},
+		Reducer: ReducerAvg,
+	}
+
+	metric, err := GetMetricFromNewJob(context.Background(), client, &spReq)
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	if metric != 21 {
+		t.Fatalf("Expected the average of the non empty results but got %v.", metric)
+	}
+
+	spReq.Reducer = ""
+	_, err = GetMetricFromNewJob(context.Background(), client, &spReq)
+	if err == nil || !strings.Contains(err.Error(), "4 results found") {
+		t.Fatalf("Expected an error for several results without reducer but got %v", err)
+	}
+
+	spReq.Reducer = "median"
+	_, err = GetMetricFromNewJob(context.Background(), client, &spReq)
+	if err == nil || !strings.Contains(err.Error(), "unknown reducer") {
+		t.Fatalf("Expected an error for an unknown reducer but got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package jobs
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// reducers collapsing the values of several results into one metric
+const (
+	ReducerSum   = "sum"
+	ReducerAvg   = "avg"
+	ReducerMin   = "min"
+	ReducerMax   = "max"
+	ReducerFirst = "first"
+	ReducerLast  = "last"
+)
+
+// a percentile reducer is p followed by a plain decimal number, the form of the perc function of splunk, e.g. p95 or p99.9
+var percentileReducer = regexp.MustCompile(`^p([0-9]+(\.[0-9]+)?)$`)
+
+// ValidateReducer returns an error if the reducer is neither sum, avg, min, max, first, last nor a percentile pN with 0 <= N <= 100
+func ValidateReducer(reducer string) error {
+	switch reducer {
+	case "", ReducerSum, ReducerAvg, ReducerMin, ReducerMax, ReducerFirst, ReducerLast:
+		return nil
+	}
+	_, err := percentileOf(reducer)
+	return err
+}
+
+// ReduceMetrics collapses the values, in the order of the results, into one metric
+// percentiles are interpolated linearly between the two closest ranks
+func ReduceMetrics(values []float64, reducer string) (float64, error) {
+	if len(values) == 0 {
+		return -1, fmt.Errorf("no value to reduce")
+	}
+
+	switch reducer {
+	case ReducerSum, ReducerAvg:
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		if reducer == ReducerAvg {
+			return sum / float64(len(values)), nil
+		}
+		return sum, nil
+	case ReducerMin:
+		min := values[0]
+		for _, value := range values[1:] {
+			min = math.Min(min, value)
+		}
+		return min, nil
+	case ReducerMax:
+		max := values[0]
+		for _, value := range values[1:] {
+			max = math.Max(max, value)
+		}
+		return max, nil
+	case ReducerFirst:
+		return values[0], nil
+	case ReducerLast:
+		return values[len(values)-1], nil
+	}
+
+	percentile, err := percentileOf(reducer)
+	if err != nil {
+		return -1, err
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+
+	rank := percentile / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower]), nil
+}
+
+// return N for the percentile reducer pN
+func percentileOf(reducer string) (float64, error) {
+	if !strings.HasPrefix(reducer, "p") {
+		return -1, fmt.Errorf("unknown reducer %q, expected sum, avg, min, max, first, last or a percentile like p95", reducer)
+	}
+	match := percentileReducer.FindStringSubmatch(reducer)
+	if match == nil {
+		return -1, fmt.Errorf("invalid percentile reducer %q, expected p followed by a number between 0 and 100", reducer)
+	}
+	percentile, err := strconv.ParseFloat(match[1], 64)
+	if err != nil || percentile > 100 {
+		return -1, fmt.Errorf("invalid percentile reducer %q, expected p followed by a number between 0 and 100", reducer)
+	}
+	return percentile, nil
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"testing"
+)
+
+func TestReduceMetrics(t *testing.T) {
+	values := []float64{4, 1, 3, 2, 10}
+	tests := []struct {
+		reducer  string
+		expected float64
+	}{
+		{ReducerSum, 20},
+		{ReducerAvg, 4},
+		{ReducerMin, 1},
+		{ReducerMax, 10},
+		{ReducerFirst, 4},
+		{ReducerLast, 10},
+		{"p0", 1},
+		{"p50", 3},
+		{"p100", 10},
+		{"p75", 4},
+		{"p62.5", 3.5},
+	}
+	for _, test := range tests {
+		metric, err := ReduceMetrics(values, test.reducer)
+		if err != nil || metric != test.expected {
+			t.Fatalf("%s : expected %v but got %v, %v", test.reducer, test.expected, metric, err)
+		}
+	}
+
+	if _, err := ReduceMetrics(nil, ReducerAvg); err == nil {
+		t.Fatal("Expected an error when there is no value to reduce")
+	}
+}
+
+func TestValidateReducer(t *testing.T) {
+	for _, reducer := range []string{"", ReducerSum, ReducerLast, "p95", "p99.9"} {
+		if err := ValidateReducer(reducer); err != nil {
+			t.Fatalf("Expected %q to be valid but got %v", reducer, err)
+		}
+	}
+	for _, reducer := range []string{"median", "p", "p101", "p-1", "pNaN", "P95", "p1e1", "p+50", "p.5", "p50.", "p0x10", "pInf", "p 50"} {
+		if err := ValidateReducer(reducer); err == nil {
+			t.Fatalf("Expected %q to be invalid", reducer)
+		}
+	}
+}
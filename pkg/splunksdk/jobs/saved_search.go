@@ -0,0 +1,146 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
+)
+
+const savedSearchesPath = "services/saved/searches/"
+
+// GetMetricFromSavedSearch returns a metric from the results of the saved search, and the last status of its job
+// if maxAge is positive, the results of the latest job of its history are used when the events they cover are at most maxAge old
+// otherwise, or if there is no such job, the saved search is dispatched over the time range of the request
+// the query of the request is ignored, its result field, reducer and timeout apply to the results of the saved search
+func GetMetricFromSavedSearch(ctx context.Context, client *splunk.SplunkClient, name string, spRequest *SearchRequest, maxAge time.Duration) (float64, *JobStatus, error) {
+
+	err := ValidateReducer(spRequest.Reducer)
+	if err != nil {
+		return -1, nil, err
+	}
+
+	if maxAge > 0 {
+		sid, err := GetFreshSavedSearchJob(ctx, client, name, maxAge)
+		if err != nil {
+			return -1, nil, err
+		}
+		if sid != "" {
+			return getMetricFromJob(ctx, client, sid, spRequest)
+		}
+	}
+
+	sid, err := DispatchSavedSearch(ctx, client, name, spRequest)
+	if err != nil {
+		return -1, nil, fmt.Errorf("error while dispatching the saved search %s : %w", name, err)
+	}
+
+	return getMetricFromJob(ctx, client, sid, spRequest)
+}
+
+// DispatchSavedSearch runs the saved search over the time range of the request, without triggering its actions, and returns the SID of its job
+func DispatchSavedSearch(ctx context.Context, client *splunk.SplunkClient, name string, spRequest *SearchRequest) (string, error) {
+
+	// the endpoint dispatching the corresponding saved search
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, savedSearchesPath+url.PathEscape(name)+"/dispatch")
+
+	params := url.Values{}
+	params.Add("output_mode", "json")
+	params.Add("trigger_actions", "0")
+	if spRequest.Params.EarliestTime != "" {
+		params.Add("dispatch.earliest_time", spRequest.Params.EarliestTime)
+	}
+	if spRequest.Params.LatestTime != "" {
+		params.Add("dispatch.latest_time", spRequest.Params.LatestTime)
+	}
+
+	headers := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	for header, value := range spRequest.Headers {
+		headers[header] = value
+	}
+	// dispatching the saved search twice would create two jobs, so the request is only sent again
+	// when splunk has certainly not processed it
+	resp, err := splunk.MakeHttpRequest(ctx, client, http.MethodPost, endpoint, headers, params)
+	if err != nil {
+		return "", fmt.Errorf("error while making the post request : %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	// handle error
+	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
+		return "", splunk.NewSplunkError(resp, body)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error while getting the body of the post request : %w", err)
+	}
+
+	return getSID(body)
+}
+
+// GetFreshSavedSearchJob returns the SID of the latest completed job of the history of the saved search
+// if the events it searched are at most maxAge old, empty if there is no such job
+func GetFreshSavedSearchJob(ctx context.Context, client *splunk.SplunkClient, name string, maxAge time.Duration) (string, error) {
+
+	// the endpoint listing the jobs of the corresponding saved search
+	endpoint := splunk.CreateNamespacedEndpoint(ctx, client, savedSearchesPath+url.PathEscape(name)+"/history") + "?output_mode=json&count=0"
+
+	resp, err := GetJob(ctx, client, endpoint)
+	if err != nil {
+		return "", fmt.Errorf("error while getting the history of the saved search %s : %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	// handle error
+	if !strings.HasPrefix(strconv.Itoa(resp.StatusCode), "2") {
+		return "", fmt.Errorf("error while getting the history of the saved search %s : %w", name, splunk.NewSplunkError(resp, body))
+	}
+	if err != nil {
+		return "", fmt.Errorf("error while getting the body of the get request : %w", err)
+	}
+
+	// the entries of the history are named by the SID of the jobs
+	var history struct {
+		Entry []struct {
+			Name      string    `json:"name"`
+			Published time.Time `json:"published"`
+		} `json:"entry"`
+	}
+	err = json.Unmarshal(body, &history)
+	if err != nil {
+		return "", fmt.Errorf("could not read the history of the saved search %s : %w", name, err)
+	}
+	sort.SliceStable(history.Entry, func(i, j int) bool {
+		return history.Entry[i].Published.After(history.Entry[j].Published)
+	})
+
+	for _, entry := range history.Entry {
+		status, err := GetJobStatus(ctx, client, entry.Name)
+		// the artifacts of the job may have expired, an older job may still be fresh enough
+		if splunk.ErrorKindOf(err) == splunk.ErrorKindNotFound {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if status.IsFailed || !(status.IsDone || status.DispatchState == DispatchStateDone) {
+			continue
+		}
+		// the older jobs are not fresher
+		latest, err := time.Parse(time.RFC3339Nano, status.LatestTime)
+		if err != nil || time.Since(latest) > maxAge {
+			return "", nil
+		}
+		return entry.Name, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
+	splunkTest "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/pkg/utils"
+)
+
+// mock of a splunk server with a saved search whose history has a job whose events end at latestTime,
+// a more recent failed job and an even more recent job whose artifacts expired
+func savedSearchServer(t *testing.T, latestTime time.Time, dispatches *int32) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/services/saved/searches/Checkout errors/dispatch", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.Method != http.MethodPost || r.PostForm.Get("trigger_actions") != "0" || r.PostForm.Get("dispatch.earliest_time") != "1610723085" ||
+			r.PostForm.Get("dispatch.latest_time") != "1610723385" {
+			t.Errorf("Expected the dispatch of the saved search over the time range but got %s %v", r.Method, r.PostForm)
+		}
+		atomic.AddInt32(dispatches, 1)
+		_, _ = w.Write([]byte(`{"sid":"admin__admin__search__dispatched"}`))
+	})
+	mux.HandleFunc("/services/saved/searches/Checkout errors/history", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"entry":[
+			{"name":"scheduler__admin__search__old","published":"2021-01-15T14:00:00Z"},
+			{"name":"scheduler__admin__search__expired","published":"2021-01-15T17:00:00Z"},
+			{"name":"scheduler__admin__search__failed","published":"2021-01-15T16:00:00Z"},
+			{"name":"scheduler__admin__search__latest","published":"2021-01-15T15:00:00Z"}]}`))
+	})
+	mux.HandleFunc("/services/search/v2/jobs/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/results") && strings.Contains(r.URL.Path, "dispatched"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"5"}]}`))
+		case strings.HasSuffix(r.URL.Path, "/results"):
+			_, _ = w.Write([]byte(`{"results":[{"count":"3"}]}`))
+		case strings.HasSuffix(r.URL.Path, "expired"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"messages":[{"type":"FATAL","text":"Unknown sid."}]}`))
+		case strings.HasSuffix(r.URL.Path, "failed"):
+			_, _ = w.Write([]byte(`{"entry":[{"content":{"dispatchState":"FAILED","isDone":true,"isFailed":true}}]}`))
+		default:
+			_, _ = w.Write([]byte(fmt.Sprintf(`{"entry":[{"content":{"dispatchState":"DONE","isDone":true,"isFailed":false,"latestTime":%q}}]}`,
+				latestTime.Format(time.RFC3339Nano))))
+		}
+	})
+	return httptest.NewTLSServer(mux)
+}
+
+func TestGetMetricFromSavedSearch(t *testing.T) {
+
+	spReq := SearchRequest{
+		Params: SearchParams{
+			EarliestTime: "1610723085",
+			LatestTime:   "1610723385",
+		},
+		Timeout: time.Minute,
+	}
+
+	tests := []struct {
+		name       string
+		latestTime time.Time
+		maxAge     time.Duration
+		metric     float64
+		sid        string
+		dispatches int32
+	}{
+		{"dispatched without maximum age", time.Now(), 0, 5, "admin__admin__search__dispatched", 1},
+		{"latest scheduled results fresh enough", time.Now().Add(-5 * time.Minute), 15 * time.Minute, 3, "scheduler__admin__search__latest", 0},
+		{"dispatched if the scheduled results are too old", time.Now().Add(-time.Hour), 15 * time.Minute, 5, "admin__admin__search__dispatched", 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var dispatches int32
+			server := savedSearchServer(t, test.latestTime, &dispatches)
+			defer server.Close()
+
+			client := splunk.NewClientAuthenticatedByToken(
+				&http.Client{
+					Timeout: time.Duration(60) * time.Second,
+				},
+				splunkTest.GetTestHostname(server),
+				splunkTest.GetTestPort(server),
+				splunkTest.GetTestToken(),
+				&tls.Config{InsecureSkipVerify: true},
+			)
+
+			metric, status, err := GetMetricFromSavedSearch(context.Background(), client, "Checkout errors", &spReq, test.maxAge)
+			if err != nil || metric != test.metric {
+				t.Fatalf("Expected %v but got %v, %v", test.metric, metric, err)
+			}
+			if status.Sid != test.sid || dispatches != test.dispatches {
+				t.Fatalf("Expected the results of job %s with %d dispatches but got %s with %d", test.sid, test.dispatches, status.Sid, dispatches)
+			}
+		})
+	}
+}
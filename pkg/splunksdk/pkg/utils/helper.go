@@ -14,6 +14,10 @@ const GetAlertsNames = "getAlertsNames"
 const GetTriggeredAlerts = "getTriggeredAlerts"
 const CreateAlerts = "createAlerts"
 const GetTriggeredInstances = "getTriggeredInstances"
+const GetJobStatus = "getJobStatus"
+
+// status returned by the mock servers for the jobs when no GetJobStatus response is set
+const DoneJobStatus = `{"entry":[{"name":"search","content":{"dispatchState":"DONE","isDone":true,"isFailed":false,"messages":[]}}]}`
 
 // mock an http server
 func MockRequest(response string, sslVerificationActivated bool) *httptest.Server {
@@ -51,6 +55,10 @@ func writeResponses(responses interface{}, w http.ResponseWriter, r *http.Reques
 
 	switch resps := responses.(type) {
 	case []map[string]interface{}:
+		if r.Method == http.MethodGet && isJobStatusPath(r.URL.Path) {
+			writeJobStatus(resps, w)
+			return
+		}
 		for _, response := range resps {
 			switch method := r.Method; method {
 			case http.MethodGet:
@@ -99,3 +107,24 @@ func GetTestToken() string {
 	}
 	return "default"
 }
+
+// return true if the path is the one of the status of a job (e.g. services/search/v2/jobs/{sid})
+func isJobStatusPath(path string) bool {
+	index := strings.Index(path, JobsPathv2)
+	if index < 0 {
+		return false
+	}
+	sid := strings.Trim(path[index+len(JobsPathv2):], "/")
+	return sid != "" && !strings.Contains(sid, "/")
+}
+
+// write the first status set in the responses, or the one of a done job
+func writeJobStatus(responses []map[string]interface{}, w http.ResponseWriter) {
+	for _, response := range responses {
+		if response[GetJobStatus] != nil {
+			_, _ = fmt.Fprintln(w, response[GetJobStatus])
+			return
+		}
+	}
+	_, _ = fmt.Fprintln(w, DoneJobStatus)
+}
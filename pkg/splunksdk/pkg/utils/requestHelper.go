@@ -1,10 +1,7 @@
 package utils
 
 import (
-	"net"
 	"strings"
-
-	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
 )
 
 func ValidateSearchQuery(searchQuery string) string {
@@ -24,18 +21,3 @@ func ValidateAlertQuery(alertQuery string) string {
 	}
 	return alertQuery
 }
-
-func CreateEndpoint(client *splunk.SplunkClient, service string) {
-	host := client.Host
-	port := client.Port
-
-	switch {
-	case strings.HasPrefix(host, "https://"):
-		host = strings.Replace(host, "https://", "", 1)
-	case strings.HasPrefix(host, "http://"):
-		host = strings.Replace(host, "http://", "", 1)
-	}
-
-	client.Endpoint = "https://" + net.JoinHostPort(host, port) + "/" + service
-	client.Endpoint = strings.ReplaceAll(client.Endpoint, " ", "")
-}
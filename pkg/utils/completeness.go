@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"fmt"
+)
+
+// what to do when the events of the end of the evaluation may not be indexed yet
+const (
+	// no completeness check
+	CompletenessNone = "none"
+	// wait for the ingestion of the events, at most the maximum ingestion lag
+	CompletenessWait = "wait"
+	// shift the time range of the search back by the ingestion lag, at most the maximum ingestion lag
+	CompletenessShift = "shift"
+	// only add a warning to the message of the indicator
+	CompletenessWarn = "warn"
+)
+
+// field of the result of the completeness query holding the ingestion lag in seconds
+const IngestionLagField = "ingestionLag"
+
+// ValidateCompletenessCheck returns an error if the completeness check is neither none, wait, shift nor warn
+func ValidateCompletenessCheck(completeness string) error {
+	switch completeness {
+	case "", CompletenessNone, CompletenessWait, CompletenessShift, CompletenessWarn:
+		return nil
+	}
+	return fmt.Errorf("unknown completeness check %q, expected %s, %s, %s or %s", completeness, CompletenessNone, CompletenessWait, CompletenessShift, CompletenessWarn)
+}
+
+// CompletenessQuery returns the search measuring the ingestion lag of the events of the query : the maximum delay between
+// the time of an event and the time splunk indexed it (_indextime), for the events of the base search of the query
+func CompletenessQuery(query string) (string, error) {
+	base := BaseSearch(query)
+	if base == "" {
+		return "", fmt.Errorf("the query starts with a generating command, the completeness of its events cannot be checked")
+	}
+	return base + " | eval " + IngestionLagField + "=_indextime-_time | stats max(" + IngestionLagField + ") as " + IngestionLagField, nil
+}
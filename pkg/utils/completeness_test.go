@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestCompletenessQuery(t *testing.T) {
+	tests := map[string]string{
+		`search index=main error | stats count`:                      `search index=main error | eval ingestionLag=_indextime-_time | stats max(ingestionLag) as ingestionLag`,
+		`search index=main "a|b" [search index=hosts | fields host]`: `search index=main "a|b" [search index=hosts | fields host] | eval ingestionLag=_indextime-_time | stats max(ingestionLag) as ingestionLag`,
+	}
+	for query, expected := range tests {
+		completenessQuery, err := CompletenessQuery(query)
+		if err != nil || completenessQuery != expected {
+			t.Fatalf("Expected %s but got %s, %v", expected, completenessQuery, err)
+		}
+	}
+
+	if _, err := CompletenessQuery("| tstats count where index=main"); err == nil {
+		t.Fatal("Expected an error for a generating command")
+	}
+}
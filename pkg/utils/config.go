@@ -1,5 +1,17 @@
 package utils
 
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// the get-sli task fails when an indicator could not be retrieved
+	SLIFailurePolicyFail = "fail"
+	// the indicators which could not be retrieved are reported as failed and lighthouse evaluates the quality gate
+	SLIFailurePolicyLighthouse = "lighthouse"
+)
+
 type EnvConfig struct {
 	// Port on which to listen for cloudevents
 	Port int `envconfig:"RCV_PORT" default:"8080"`
@@ -17,11 +29,110 @@ type EnvConfig struct {
 	SplunkUsername   string `envconfig:"SP_USERNAME" default:""`
 	SplunkPassword   string `envconfig:"SP_PASSWORD" default:""`
 	SplunkSessionKey string `envconfig:"SP_SESSION_KEY" default:""`
+	// Full url of the splunk REST API, e.g. https://gateway/splunk-api/. Takes precedence over SP_HOST and SP_PORT
+	SplunkURL string `envconfig:"SP_URL" default:""`
+	// One of token, sessionKey, basic or login. If empty, it is deduced from the credentials provided
+	SplunkAuthMethod string `envconfig:"SP_AUTH_METHOD" default:""`
+	// Url of splunk web, e.g. https://splunk:8000, used to link the indicators and problems to their searches
+	SplunkWebURL string `envconfig:"SP_WEB_URL" default:""`
+
+	// Namespace (servicesNS/owner/app) of the searches and alerts. The global services/ endpoints are used if both are empty
+	SplunkOwner string `envconfig:"SP_OWNER" default:""`
+	SplunkApp   string `envconfig:"SP_APP" default:""`
+	// Namespaces of specific projects written as owner/app or app, e.g. "project1:admin/search,project2:keptn"
+	SplunkProjectNamespaces map[string]string `envconfig:"SP_PROJECT_NAMESPACES" default:""`
+
+	// TLS settings of the connection to splunk. The certificate of splunk is verified unless SP_SKIP_TLS_VERIFY is true
+	SplunkSkipTLSVerify bool   `envconfig:"SP_SKIP_TLS_VERIFY" default:"false"`
+	SplunkCABundle      string `envconfig:"SP_CA_BUNDLE" default:""`
+	SplunkClientCert    string `envconfig:"SP_CLIENT_CERT" default:""`
+	SplunkClientKey     string `envconfig:"SP_CLIENT_KEY" default:""`
+	SplunkTLSServerName string `envconfig:"SP_TLS_SERVER_NAME" default:""`
+	SplunkTLSMinVersion string `envconfig:"SP_TLS_MIN_VERSION" default:"1.2"`
+
+	// Retry policy applied to the requests sent to splunk
+	RetryMaxAttempts     int           `envconfig:"SP_RETRY_MAX_ATTEMPTS" default:"3"`
+	RetryInitialBackoff  time.Duration `envconfig:"SP_RETRY_INITIAL_BACKOFF" default:"500ms"`
+	RetryMaxBackoff      time.Duration `envconfig:"SP_RETRY_MAX_BACKOFF" default:"10s"`
+	RetryJitter          float64       `envconfig:"SP_RETRY_JITTER" default:"0.2"`
+	RetryableStatusCodes []int         `envconfig:"SP_RETRY_STATUS_CODES" default:"429,502,503,504"`
+
+	// Maximum duration of a SLI search, the search job is cancelled when exceeded
+	SearchTimeout time.Duration `envconfig:"SP_SEARCH_TIMEOUT" default:"2m"`
+	// What to do when some indicators could not be retrieved : fail or lighthouse
+	SLIFailurePolicy string `envconfig:"SLI_FAILURE_POLICY" default:"fail"`
+	// Maximum number of SLI searches running at the same time in splunk for a get-sli event
+	MaxConcurrentSearches int `envconfig:"SP_MAX_CONCURRENT_SEARCHES" default:"4"`
+	// IANA timezone, e.g. Europe/Paris, in which the relative time modifiers are evaluated. If empty, splunk evaluates them in the timezone of its user
+	Timezone string `envconfig:"SP_TIMEZONE" default:""`
+	// Check of the ingestion of the events at the end of the evaluation window : none, wait, shift or warn
+	CompletenessCheck string `envconfig:"SP_COMPLETENESS_CHECK" default:"none"`
+	// Maximum ingestion lag waited for or compensated by the completeness check
+	MaxIngestionLag time.Duration `envconfig:"SP_MAX_INGESTION_LAG" default:"5m"`
+	// Whether the indicators whose search job has warnings, e.g. truncated results, fail
+	FailOnJobWarnings bool `envconfig:"SP_FAIL_ON_JOB_WARNINGS" default:"false"`
+	// Whether the indicators sharing a base search and a time range are searched in a single job
+	BatchSearches bool `envconfig:"SP_BATCH_SEARCHES" default:"false"`
 
 	AlertSuppressPeriod  string `envconfig:"ALERT_SUPPRESS_PERIOD" default:"3m"`
 	CronSchedule         string `envconfig:"CRON_SCHEDULE" default:"3m"`
-	DispatchEarliestTime string `envconfig:"DISPATCH_EARLIEST_TIME" default:"*/1 * * * *"`
+	DispatchEarliestTime string `envconfig:"DISPATCH_EARLIEST_TIME" default:"-3m"`
 	DispatchLatestTime   string `envconfig:"DISPATCH_LATEST_TIME" default:"now"`
 	Actions              string `envconfig:"ACTIONS" default:""`
 	WebhookUrl           string `envconfig:"WEBHOOK_URL" default:""`
+	// Sharing (user, app or global) and comma separated read and write roles of the alerts. The ACL is left unchanged if ALERT_SHARING is empty
+	AlertSharing    string `envconfig:"ALERT_SHARING" default:""`
+	AlertReadRoles  string `envconfig:"ALERT_READ_ROLES" default:""`
+	AlertWriteRoles string `envconfig:"ALERT_WRITE_ROLES" default:""`
+}
+
+// CheckSLIConfig returns an error if the configuration of the get-sli task is invalid
+func CheckSLIConfig(env EnvConfig) error {
+	switch env.SLIFailurePolicy {
+	case SLIFailurePolicyFail, SLIFailurePolicyLighthouse:
+	default:
+		return fmt.Errorf("invalid SLI_FAILURE_POLICY %s, should be one of %s or %s", env.SLIFailurePolicy, SLIFailurePolicyFail, SLIFailurePolicyLighthouse)
+	}
+	if _, err := GetTimezone(env); err != nil {
+		return err
+	}
+	if err := ValidateWebURL(env.SplunkWebURL); err != nil {
+		return fmt.Errorf("invalid SP_WEB_URL : %w", err)
+	}
+	if env.MaxConcurrentSearches < 1 {
+		return fmt.Errorf("invalid SP_MAX_CONCURRENT_SEARCHES %d, at least one search has to be allowed", env.MaxConcurrentSearches)
+	}
+	if err := ValidateCompletenessCheck(env.CompletenessCheck); err != nil {
+		return fmt.Errorf("invalid SP_COMPLETENESS_CHECK : %w", err)
+	}
+	if env.MaxIngestionLag < 0 {
+		return fmt.Errorf("invalid SP_MAX_INGESTION_LAG %v, it should not be negative", env.MaxIngestionLag)
+	}
+	return nil
+}
+
+// CheckAlertConfig returns an error if the configuration of the alerts is invalid
+func CheckAlertConfig(env EnvConfig) error {
+	if err := ValidateSplunkTime(env.DispatchEarliestTime); err != nil {
+		return fmt.Errorf("invalid DISPATCH_EARLIEST_TIME : %w", err)
+	}
+	if err := ValidateSplunkTime(env.DispatchLatestTime); err != nil {
+		return fmt.Errorf("invalid DISPATCH_LATEST_TIME : %w", err)
+	}
+	if err := ValidateWebURL(env.SplunkWebURL); err != nil {
+		return fmt.Errorf("invalid SP_WEB_URL : %w", err)
+	}
+	return nil
+}
+
+// GetTimezone returns the location in which the relative time modifiers are evaluated, nil if splunk evaluates them
+func GetTimezone(env EnvConfig) (*time.Location, error) {
+	if env.Timezone == "" {
+		return nil, nil
+	}
+	location, err := time.LoadLocation(env.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SP_TIMEZONE %s : %w", env.Timezone, err)
+	}
+	return location, nil
 }
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestCheckSLIConfig(t *testing.T) {
+	env := EnvConfig{SLIFailurePolicy: SLIFailurePolicyLighthouse, MaxConcurrentSearches: 4}
+	if err := CheckSLIConfig(env); err != nil {
+		t.Fatalf("Expected the configuration to be valid but got %v", err)
+	}
+
+	env.SLIFailurePolicy = "ignore"
+	if err := CheckSLIConfig(env); err == nil {
+		t.Fatal("Expected an error for an unknown failure policy")
+	}
+
+	env = EnvConfig{SLIFailurePolicy: SLIFailurePolicyFail}
+	if err := CheckSLIConfig(env); err == nil {
+		t.Fatal("Expected an error when no search is allowed")
+	}
+
+	env = EnvConfig{SLIFailurePolicy: SLIFailurePolicyFail, MaxConcurrentSearches: 1, Timezone: "Mars/Olympus_Mons"}
+	if err := CheckSLIConfig(env); err == nil {
+		t.Fatal("Expected an error for an unknown timezone")
+	}
+
+	env = EnvConfig{SLIFailurePolicy: SLIFailurePolicyFail, MaxConcurrentSearches: 1, CompletenessCheck: "retry"}
+	if err := CheckSLIConfig(env); err == nil {
+		t.Fatal("Expected an error for an unknown completeness check")
+	}
+
+	env = EnvConfig{SLIFailurePolicy: SLIFailurePolicyFail, MaxConcurrentSearches: 1, SplunkWebURL: "splunk:8000"}
+	if err := CheckSLIConfig(env); err == nil {
+		t.Fatal("Expected an error for a splunk web url without scheme")
+	}
+}
+
+func TestCheckAlertConfig(t *testing.T) {
+	env := EnvConfig{DispatchEarliestTime: "-3m", DispatchLatestTime: "now"}
+	if err := CheckAlertConfig(env); err != nil {
+		t.Fatalf("Expected the configuration to be valid but got %v", err)
+	}
+
+	// a cron expression used to be the default earliest time
+	env.DispatchEarliestTime = "*/1 * * * *"
+	if err := CheckAlertConfig(env); err == nil {
+		t.Fatal("Expected an error for an earliest time which is not a splunk time")
+	}
+}
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+)
+
+// placeholder of the queries replaced by the custom filters of the get-sli event
+const CustomFiltersPlaceholder = "$CUSTOM_FILTERS"
+
+var fieldNameRegex = regexp.MustCompile(`^[A-Za-z_][\w.:-]*$`)
+
+// ApplyCustomFilters expands the variables of the query and adds the custom filters of the get-sli event, as key="value" search terms
+// they replace the $CUSTOM_FILTERS placeholder if the query has one, otherwise they are added to the base search, before the first pipe
+// the variables are expanded before the filters are added, so that the values of the filters are never read as variables,
+// nor the values of the variables as the placeholder
+func ApplyCustomFilters(query string, customFilters []*keptnv2.SLIFilter, variables map[string]string) (string, error) {
+	var terms []string
+	for _, filter := range customFilters {
+		if filter == nil {
+			continue
+		}
+		if !fieldNameRegex.MatchString(filter.Key) {
+			return "", fmt.Errorf("invalid custom filter key %q, expected a splunk field name", filter.Key)
+		}
+		terms = append(terms, filter.Key+`="`+escapeQuotedString(filter.Value)+`"`)
+	}
+	filters := strings.Join(terms, " ")
+
+	// the placeholder marks where the filters go
+	if !strings.Contains(query, CustomFiltersPlaceholder) && filters != "" {
+		pipe := firstTopLevelPipe(query)
+		switch {
+		case pipe == -1:
+			query = query + " " + CustomFiltersPlaceholder
+		case strings.TrimSpace(query[:pipe]) == "":
+			return "", fmt.Errorf("the query starts with a generating command, the custom filters can only be added where it has the %s placeholder", CustomFiltersPlaceholder)
+		default:
+			query = strings.TrimRight(query[:pipe], " ") + " " + CustomFiltersPlaceholder + " " + query[pipe:]
+		}
+	}
+
+	var sb strings.Builder
+	inQuotes := false
+	for i, part := range strings.Split(query, CustomFiltersPlaceholder) {
+		if i > 0 {
+			sb.WriteString(filters)
+		}
+		expanded, endInQuotes, err := expandQueryVariables(part, variables, inQuotes)
+		if err != nil {
+			return "", err
+		}
+		inQuotes = endInQuotes
+		sb.WriteString(expanded)
+	}
+	return sb.String(), nil
+}
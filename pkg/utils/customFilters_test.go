@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+)
+
+func TestApplyCustomFilters(t *testing.T) {
+	filters := []*keptnv2.SLIFilter{
+		{Key: "region", Value: "eu-west-1"},
+		{Key: "pod", Value: `carts-1" | delete`},
+	}
+
+	tests := []struct {
+		query    string
+		filters  []*keptnv2.SLIFilter
+		expected string
+	}{
+		{`index=main "[error]" | stats count`, filters, `index=main "[error]" region="eu-west-1" pod="carts-1\" | delete" | stats count`},
+		{`index=main host="a|b" [search index=hosts | fields host] | stats count`, filters[:1], `index=main host="a|b" [search index=hosts | fields host] region="eu-west-1" | stats count`},
+		{`index=main`, filters[:1], `index=main region="eu-west-1"`},
+		{`| tstats count where index=main $CUSTOM_FILTERS`, filters[:1], `| tstats count where index=main region="eu-west-1"`},
+		{`index=main $CUSTOM_FILTERS | stats count`, nil, `index=main  | stats count`},
+		{`index=main | stats count`, nil, `index=main | stats count`},
+	}
+	for _, test := range tests {
+		query, err := ApplyCustomFilters(test.query, test.filters, nil)
+		if err != nil || query != test.expected {
+			t.Fatalf("Expected %s but got %s, %v", test.expected, query, err)
+		}
+	}
+
+	if _, err := ApplyCustomFilters("index=main | stats count", []*keptnv2.SLIFilter{{Key: "a | delete", Value: "x"}}, nil); err == nil {
+		t.Fatal("Expected an error for a key which is not a field name")
+	}
+	if _, err := ApplyCustomFilters("| tstats count where index=main", filters, nil); err == nil {
+		t.Fatal("Expected an error for a generating command without placeholder")
+	}
+}
+
+// Tests that the values of the filters are not read as variables, nor the values of the variables as the placeholder
+func TestApplyCustomFiltersWithVariables(t *testing.T) {
+	variables := map[string]string{"PROJECT": "sockshop", "LABEL.owner": "$CUSTOM_FILTERS"}
+	filters := []*keptnv2.SLIFilter{
+		{Key: "message", Value: "cost of $PROJECT"},
+		{Key: "tag", Value: "$LABEL.x"},
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`index=main project=$PROJECT | stats count`, `index=main project="sockshop" message="cost of $PROJECT" tag="$LABEL.x" | stats count`},
+		{`index=main owner=$LABEL.owner $CUSTOM_FILTERS | stats count`, `index=main owner="$CUSTOM_FILTERS" message="cost of $PROJECT" tag="$LABEL.x" | stats count`},
+		{`index=main $CUSTOM_FILTERS msg="project $PROJECT"`, `index=main message="cost of $PROJECT" tag="$LABEL.x" msg="project sockshop"`},
+	}
+	for _, test := range tests {
+		query, err := ApplyCustomFilters(test.query, filters, variables)
+		if err != nil || query != test.expected {
+			t.Fatalf("Expected %s but got %s, %v", test.expected, query, err)
+		}
+	}
+
+	if _, err := ApplyCustomFilters("index=main version=$LABEL.missing", filters, variables); err == nil || !strings.Contains(err.Error(), "unknown variable $LABEL.missing") {
+		t.Fatalf("Expected an error for an unknown variable of the query but got %v", err)
+	}
+}
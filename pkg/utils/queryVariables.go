@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+)
+
+// variables of the queries : $PROJECT, $STAGE, $SERVICE, $DEPLOYMENT, $START, $END and $LABEL.<name>
+var queryVariableRegex = regexp.MustCompile(`\$(PROJECT|STAGE|SERVICE|DEPLOYMENT|START|END|LABEL\.[\w-]+)\b`)
+
+var numberRegex = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// GetSLIQueryVariables returns the values of the variables of the queries for the get-sli event
+// the start and end of the evaluation are given in epoch seconds
+func GetSLIQueryVariables(data *keptnv2.GetSLITriggeredEventData) map[string]string {
+	variables := getEventQueryVariables(data.EventData)
+	variables["DEPLOYMENT"] = data.Deployment
+	variables["START"] = toEpochSeconds(data.GetSLI.Start)
+	variables["END"] = toEpochSeconds(data.GetSLI.End)
+	return variables
+}
+
+// GetAlertQueryVariables returns the values of the variables of the queries of the alerts created for the configure monitoring event
+// the alerts run on a schedule, so there is no deployment nor evaluation time range
+func GetAlertQueryVariables(data keptnv2.ConfigureMonitoringTriggeredEventData, stage string) map[string]string {
+	variables := getEventQueryVariables(data.EventData)
+	variables["STAGE"] = stage
+	return variables
+}
+
+func getEventQueryVariables(data keptnv2.EventData) map[string]string {
+	variables := map[string]string{
+		"PROJECT": data.Project,
+		"STAGE":   data.Stage,
+		"SERVICE": data.Service,
+	}
+	for name, value := range data.Labels {
+		variables["LABEL."+name] = value
+	}
+	return variables
+}
+
+// ExpandQueryVariables replaces the variables of the query by their values
+// the values are escaped so that they are always read by splunk as a single value : inside a quoted string, the quotes and backslashes
+// of the value are escaped, elsewhere the value is quoted unless it is a number
+func ExpandQueryVariables(query string, variables map[string]string) (string, error) {
+	expanded, _, err := expandQueryVariables(query, variables, false)
+	return expanded, err
+}
+
+// expand the variables of a part of a query, given whether its beginning is inside a quoted string,
+// and return whether its end is
+func expandQueryVariables(query string, variables map[string]string, inQuotes bool) (string, bool, error) {
+	var sb strings.Builder
+	last := 0
+
+	for _, match := range queryVariableRegex.FindAllStringSubmatchIndex(query, -1) {
+		inQuotes = isInQuotes(query[last:match[0]], inQuotes)
+		sb.WriteString(query[last:match[0]])
+		last = match[1]
+
+		name := query[match[2]:match[3]]
+		value, ok := variables[name]
+		if !ok {
+			return "", inQuotes, fmt.Errorf("unknown variable $%s in the query", name)
+		}
+		switch {
+		case inQuotes:
+			sb.WriteString(escapeQuotedString(value))
+		case numberRegex.MatchString(value):
+			sb.WriteString(value)
+		default:
+			sb.WriteString(`"` + escapeQuotedString(value) + `"`)
+		}
+	}
+	inQuotes = isInQuotes(query[last:], inQuotes)
+	sb.WriteString(query[last:])
+
+	return sb.String(), inQuotes, nil
+}
+
+// return whether the end of the query part is inside a quoted string, given whether its beginning is
+func isInQuotes(part string, inQuotes bool) bool {
+	escaped := false
+	for _, c := range part {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\' && inQuotes:
+			escaped = true
+		case c == '"':
+			inQuotes = !inQuotes
+		}
+	}
+	return inQuotes
+}
+
+func escapeQuotedString(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+}
+
+// return the epoch time in seconds of the given timestamp, the timestamp itself if it cannot be parsed
+func toEpochSeconds(timestamp string) string {
+	epoch, err := NormalizeSplunkTime(timestamp, nil, time.Now())
+	if err != nil {
+		return timestamp
+	}
+	return epoch
+}
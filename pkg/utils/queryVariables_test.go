@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	keptnv2 "github.com/keptn/go-utils/pkg/lib/v0_2_0"
+)
+
+func TestExpandQueryVariables(t *testing.T) {
+	data := &keptnv2.GetSLITriggeredEventData{
+		EventData: keptnv2.EventData{
+			Project: "sockshop",
+			Stage:   "hardening",
+			Service: "carts",
+			Labels:  map[string]string{"version": "1.2.0", "owner": `x" | delete`},
+		},
+		Deployment: "canary",
+	}
+	data.GetSLI.Start = "2023-07-18T10:00:00.000Z"
+	data.GetSLI.End = "2023-07-18T10:05:00.000Z"
+	variables := GetSLIQueryVariables(data)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`index=main service=$SERVICE stage="$STAGE" | stats count`, `index=main service="carts" stage="hardening" | stats count`},
+		{`index=$PROJECT deployment=$DEPLOYMENT version=$LABEL.version`, `index="sockshop" deployment="canary" version="1.2.0"`},
+		{`index=main earliest=$START latest=$END`, `index=main earliest=1689674400 latest=1689674700`},
+		{`index=main owner=$LABEL.owner`, `index=main owner="x\" | delete"`},
+		{`index=main owner="team \"$LABEL.owner\""`, `index=main owner="team \"x\" | delete\""`},
+		{`index=main $SERVICE_NAME`, `index=main $SERVICE_NAME`},
+	}
+	for _, test := range tests {
+		query, err := ExpandQueryVariables(test.query, variables)
+		if err != nil || query != test.expected {
+			t.Fatalf("Expected %s but got %s, %v", test.expected, query, err)
+		}
+	}
+
+	_, err := ExpandQueryVariables("index=main version=$LABEL.missing", variables)
+	if err == nil || !strings.Contains(err.Error(), "$LABEL.missing") {
+		t.Fatalf("Expected an error for an unknown label but got %v", err)
+	}
+	_, err = ExpandQueryVariables("index=main earliest=$START", GetAlertQueryVariables(keptnv2.ConfigureMonitoringTriggeredEventData{}, "hardening"))
+	if err == nil {
+		t.Fatal("Expected the evaluation time range to be unknown in the alerts")
+	}
+}
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	splunkjobs "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/jobs"
+
+	api "github.com/keptn/go-utils/pkg/api/utils"
+	"gopkg.in/yaml.v2"
+)
+
+// what to do when the search of an indicator returns no data, or only null values
+const (
+	// the indicator fails
+	NoDataError = "error"
+	// the indicator has its default value, 0 if it has none
+	NoDataValue = "value"
+	// the indicator is reported as skipped, without failing the get-sli task
+	NoDataSkip = "skip"
+)
+
+// SLIDefinition is an indicator of the sli.yaml file, written either as a splunk search
+//
+//	indicators:
+//	  error_count: "search index=main error | stats count"
+//
+// or as a mapping
+//
+//	indicators:
+//	  response_time:
+//	    query: "search index=main | stats avg(duration) as avg, count"
+//	    field: avg
+//	  max_latency:
+//	    query: "search index=main | timechart span=1m max(duration)"
+//	    reducer: p95
+//	    timeout: 5m
+//	    default: 0
+//	    noData: value
+//	    unit: ms
+//	    earliest: "-1h"
+//	    latest: "now"
+//	    app: web
+//	    completeness: wait
+//	    maxIngestionLag: 2m
+//	    failOnWarnings: true
+//	    batch: true
+//	  checkout_errors:
+//	    savedSearch: "Checkout errors"
+//	    maxAge: 15m
+type SLIDefinition struct {
+	Query string `yaml:"query"`
+	// name of a saved search or report of splunk run instead of the query
+	SavedSearch string `yaml:"savedSearch"`
+	// maximum age of the events of the latest scheduled results of the saved search for them to be used instead of dispatching it
+	MaxAge time.Duration `yaml:"maxAge"`
+	// name of the field of the search result holding the value of the indicator
+	Field string `yaml:"field"`
+	// collapses the values of several search results into one : sum, avg, min, max, first, last or a percentile pN
+	Reducer string `yaml:"reducer"`
+	// maximum duration of the search, SP_SEARCH_TIMEOUT if not set
+	Timeout time.Duration `yaml:"timeout"`
+	// value of the indicator when the search returns no data
+	Default *float64 `yaml:"default"`
+	// what to do when the search returns no data : error, value or skip. By default value if there is a default value, error otherwise
+	NoData string `yaml:"noData"`
+	// unit of the value, only informative
+	Unit string `yaml:"unit"`
+	// time range of the search, overriding the one of the evaluation
+	Earliest string `yaml:"earliest"`
+	Latest   string `yaml:"latest"`
+	// splunk app in which the search runs, overriding the one of the project
+	App string `yaml:"app"`
+	// check of the ingestion of the events at the end of the time range : none, wait, shift or warn, SP_COMPLETENESS_CHECK if not set
+	Completeness string `yaml:"completeness"`
+	// maximum ingestion lag waited for or compensated, SP_MAX_INGESTION_LAG if not set
+	MaxIngestionLag time.Duration `yaml:"maxIngestionLag"`
+	// whether the indicator fails when its search job has warnings, SP_FAIL_ON_JOB_WARNINGS if not set
+	FailOnWarnings *bool `yaml:"failOnWarnings"`
+	// whether the search of the indicator runs in a single job with the other batched indicators of the same time range,
+	// by default if SP_BATCH_SEARCHES is set and another indicator shares its base search
+	Batch *bool `yaml:"batch"`
+}
+
+type sliConfig struct {
+	SpecVersion string                   `yaml:"spec_version"`
+	Indicators  map[string]SLIDefinition `yaml:"indicators"`
+}
+
+// settings of the indicators holding a duration, which yaml would read as nanoseconds if written without unit
+var durationSettings = []string{"timeout", "maxIngestionLag", "maxAge"}
+
+// UnmarshalYAML reads an indicator written as a splunk search or as a mapping
+func (d *SLIDefinition) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var query string
+	if err := unmarshal(&query); err == nil {
+		*d = SLIDefinition{Query: query}
+		return nil
+	}
+
+	type plainDefinition SLIDefinition
+	var definition plainDefinition
+	if err := unmarshal(&definition); err != nil {
+		return fmt.Errorf("an indicator should be a splunk search or a mapping with a query or a saved search : %w", err)
+	}
+
+	var settings map[string]interface{}
+	if err := unmarshal(&settings); err != nil {
+		return err
+	}
+	for _, setting := range durationSettings {
+		if value, ok := settings[setting]; ok {
+			if _, isString := value.(string); !isString {
+				return fmt.Errorf("invalid %s %v : a duration needs a unit, e.g. 5m", setting, value)
+			}
+		}
+	}
+
+	*d = SLIDefinition(definition)
+	return nil
+}
+
+// Validate returns an error describing every invalid setting of the indicator
+func (d SLIDefinition) Validate() error {
+	var problems []string
+	switch {
+	case strings.TrimSpace(d.Query) == "" && strings.TrimSpace(d.SavedSearch) == "":
+		problems = append(problems, "the query or saved search is missing")
+	case d.Query != "" && d.SavedSearch != "":
+		problems = append(problems, "the query and the saved search are exclusive")
+	}
+	if d.MaxAge < 0 {
+		problems = append(problems, fmt.Sprintf("the maximum age %v is negative", d.MaxAge))
+	}
+	if d.MaxAge > 0 && d.SavedSearch == "" {
+		problems = append(problems, "the maximum age only applies to a saved search")
+	}
+	if err := splunkjobs.ValidateReducer(d.Reducer); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if d.Timeout < 0 {
+		problems = append(problems, fmt.Sprintf("the timeout %v is negative", d.Timeout))
+	}
+	if err := ValidateSplunkTime(d.Earliest); err != nil {
+		problems = append(problems, "earliest : "+err.Error())
+	}
+	if err := ValidateSplunkTime(d.Latest); err != nil {
+		problems = append(problems, "latest : "+err.Error())
+	}
+	switch d.NoData {
+	case "", NoDataError, NoDataValue, NoDataSkip:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown no data policy %q, expected %s, %s or %s", d.NoData, NoDataError, NoDataValue, NoDataSkip))
+	}
+	if err := ValidateCompletenessCheck(d.Completeness); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if d.MaxIngestionLag < 0 {
+		problems = append(problems, fmt.Sprintf("the maximum ingestion lag %v is negative", d.MaxIngestionLag))
+	}
+	if d.Batch != nil && *d.Batch && (d.SavedSearch != "" || d.Reducer != "" || (d.Completeness != "" && d.Completeness != CompletenessNone)) {
+		problems = append(problems, "an indicator with a saved search, a reducer or a completeness check cannot be batched")
+	}
+	if strings.Contains(d.App, "/") {
+		problems = append(problems, fmt.Sprintf("invalid app %s", d.App))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, " ; "))
+	}
+	return nil
+}
+
+// NoDataPolicy returns what to do when the search of the indicator returns no data
+func (d SLIDefinition) NoDataPolicy() string {
+	switch {
+	case d.NoData != "":
+		return d.NoData
+	case d.Default != nil:
+		return NoDataValue
+	}
+	return NoDataError
+}
+
+// DefaultValue returns the value of the indicator when its search returns no data
+func (d SLIDefinition) DefaultValue() float64 {
+	if d.Default == nil {
+		return 0
+	}
+	return *d.Default
+}
+
+// SavedSearchQuery returns the search running the saved search, as shown in splunk web
+func SavedSearchQuery(name string) string {
+	return `| savedsearch "` + escapeQuotedString(name) + `"`
+}
+
+// GetSLIDefinitions returns the indicators of the sli file of the service
+// like keptn does, the indicators of the project are overridden by the ones of the stage, which are overridden by the ones of the service
+func GetSLIDefinitions(resourceHandler *api.ResourceHandler, project string, stage string, service string, resourceURI string) (map[string]SLIDefinition, error) {
+	definitions := make(map[string]SLIDefinition)
+
+	scopes := []*api.ResourceScope{}
+	if project != "" {
+		scopes = append(scopes, api.NewResourceScope().Project(project).Resource(resourceURI))
+	}
+	if project != "" && stage != "" {
+		scopes = append(scopes, api.NewResourceScope().Project(project).Stage(stage).Resource(resourceURI))
+	}
+	if project != "" && stage != "" && service != "" {
+		scopes = append(scopes, api.NewResourceScope().Project(project).Stage(stage).Service(service).Resource(resourceURI))
+	}
+
+	for _, scope := range scopes {
+		resource, err := resourceHandler.GetResource(*scope)
+		if errors.Is(err, api.ResourceNotFoundError) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		config, err := parseSLIConfig([]byte(resource.ResourceContent))
+		if err != nil {
+			return nil, fmt.Errorf("invalid sli file %s : %w", resourceURI, err)
+		}
+		for indicatorName, definition := range config.Indicators {
+			definitions[indicatorName] = definition
+		}
+		if len(definitions) == 0 {
+			return nil, fmt.Errorf("missing required field: indicators")
+		}
+	}
+
+	return definitions, nil
+}
+
+// parse the sli file, rejecting the unknown settings so that a misspelled one is not silently ignored
+func parseSLIConfig(content []byte) (sliConfig, error) {
+	config := sliConfig{}
+	err := yaml.UnmarshalStrict(content, &config)
+	return config, err
+}
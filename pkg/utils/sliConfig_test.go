@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Tests that the indicators can be written as a search or as a mapping
+func TestUnmarshalSLIDefinition(t *testing.T) {
+	content := `
+spec_version: '1.0'
+indicators:
+  error_count: search index=main error | stats count
+  response_time:
+    query: search index=main | stats avg(duration) as avg, count
+    field: avg
+  max_latency:
+    query: search index=main | timechart span=1m max(duration)
+    reducer: p95
+`
+	config := sliConfig{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+
+	expected := map[string]SLIDefinition{
+		"error_count":   {Query: "search index=main error | stats count"},
+		"response_time": {Query: "search index=main | stats avg(duration) as avg, count", Field: "avg"},
+		"max_latency":   {Query: "search index=main | timechart span=1m max(duration)", Reducer: "p95"},
+	}
+	if len(config.Indicators) != len(expected) {
+		t.Fatalf("Expected %v but got %v", expected, config.Indicators)
+	}
+	for name, definition := range expected {
+		if config.Indicators[name] != definition {
+			t.Fatalf("Expected %v for %s but got %v", definition, name, config.Indicators[name])
+		}
+	}
+
+	if err := yaml.Unmarshal([]byte("indicators:\n  error_count: [a, b]\n"), &config); err == nil {
+		t.Fatal("Expected an error for an indicator which is neither a search nor a mapping")
+	}
+}
+
+// Tests the structured form of the indicators and its validation
+func TestSLIDefinitionSettings(t *testing.T) {
+	content := `
+indicators:
+  max_latency:
+    query: search index=main | timechart span=1m max(duration)
+    reducer: p95
+    timeout: 5m
+    default: 0
+    noData: skip
+    unit: ms
+    earliest: -1h
+    latest: now
+    app: web
+    completeness: shift
+    maxIngestionLag: 2m
+`
+	config := sliConfig{}
+	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	definition := config.Indicators["max_latency"]
+	if definition.Timeout != 5*time.Minute || definition.Default == nil || *definition.Default != 0 || definition.NoDataPolicy() != NoDataSkip || definition.Unit != "ms" ||
+		definition.Earliest != "-1h" || definition.Latest != "now" || definition.App != "web" ||
+		definition.Completeness != CompletenessShift || definition.MaxIngestionLag != 2*time.Minute {
+		t.Fatalf("Expected all the settings to be read but got %+v", definition)
+	}
+	if err := definition.Validate(); err != nil {
+		t.Fatalf("Expected the definition to be valid but got %v", err)
+	}
+
+	if (SLIDefinition{}).NoDataPolicy() != NoDataError || (SLIDefinition{Default: definition.Default}).NoDataPolicy() != NoDataValue {
+		t.Fatal("Expected the indicators to fail without data unless they have a default value")
+	}
+
+	definition = SLIDefinition{Reducer: "median", Timeout: -time.Second, Completeness: "retry", NoData: "zero"}
+	err := definition.Validate()
+	if err == nil || !strings.Contains(err.Error(), "the query or saved search is missing") || !strings.Contains(err.Error(), "unknown reducer") || !strings.Contains(err.Error(), "negative") ||
+		!strings.Contains(err.Error(), "unknown completeness check") || !strings.Contains(err.Error(), "unknown no data policy") {
+		t.Fatalf("Expected all the problems of the definition but got %v", err)
+	}
+
+	if err := (SLIDefinition{SavedSearch: "Checkout errors", MaxAge: 15 * time.Minute}).Validate(); err != nil {
+		t.Fatalf("Expected a saved search to be a valid source of the indicator but got %v", err)
+	}
+	err = SLIDefinition{Query: "search index=main | stats count", SavedSearch: "Checkout errors"}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "exclusive") {
+		t.Fatalf("Expected the query and the saved search to be exclusive but got %v", err)
+	}
+	err = SLIDefinition{Query: "search index=main | stats count", MaxAge: time.Minute}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "only applies to a saved search") {
+		t.Fatalf("Expected the maximum age to require a saved search but got %v", err)
+	}
+	batch := true
+	err = SLIDefinition{Query: "search index=main | timechart count", Reducer: "max", Batch: &batch}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "cannot be batched") {
+		t.Fatalf("Expected an indicator with a reducer not to be batchable but got %v", err)
+	}
+
+	if err := yaml.Unmarshal([]byte("indicators:\n  max_latency:\n    query: search\n    timeout: 5 minutes\n"), &config); err == nil {
+		t.Fatal("Expected an error for an invalid timeout")
+	}
+}
+
+// Tests that the misspelled settings and the durations without unit are rejected instead of being ignored
+func TestParseSLIConfigStrict(t *testing.T) {
+	tests := map[string]string{
+		"spec_version: '1.0'\nindicators:\n  response_time:\n    query: search index=main | stats avg(duration) as avg, count\n    feild: avg\n": "feild",
+		"indicators:\n  error_count:\n    query: search index=main error | stats count\n    timeout: 5\n":                                        "a duration needs a unit",
+		"indicators:\n  error_count:\n    savedSearch: Errors\n    maxAge: 900\n":                                                                "a duration needs a unit",
+		"indicators:\n  error_count: search index=main error | stats count\nmetrics: {}\n":                                                       "metrics",
+	}
+	for content, expected := range tests {
+		if _, err := parseSLIConfig([]byte(content)); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected an error mentioning %q for %q but got %v", expected, content, err)
+		}
+	}
+
+	config, err := parseSLIConfig([]byte("spec_version: '1.0'\nindicators:\n  error_count:\n    query: search index=main error | stats count\n    timeout: 90s\n"))
+	if err != nil || config.Indicators["error_count"].Timeout != 90*time.Second {
+		t.Fatalf("Expected a valid sli file but got %+v, %v", config, err)
+	}
+}
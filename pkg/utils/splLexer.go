@@ -0,0 +1,127 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+type splTokenKind int
+
+const (
+	// a search term, e.g. index=main, "quoted string" or key="quoted value"
+	splTerm splTokenKind = iota
+	splPipe
+	// brackets of a subsearch
+	splOpenBracket
+	splCloseBracket
+)
+
+// splToken is a token of a splunk search, located by its byte offsets in the search
+type splToken struct {
+	kind  splTokenKind
+	text  string
+	start int
+	end   int
+	// number of subsearches the token is in
+	depth int
+}
+
+// lexSPL splits the splunk search into terms, pipes and subsearch brackets
+// quoted strings, in which pipes and brackets have no meaning and quotes can be escaped by a backslash, are part of the terms
+func lexSPL(search string) []splToken {
+	var tokens []splToken
+	depth := 0
+	termStart := -1
+	inQuotes := false
+	escaped := false
+
+	endTerm := func(end int) {
+		if termStart != -1 {
+			tokens = append(tokens, splToken{kind: splTerm, text: search[termStart:end], start: termStart, end: end, depth: depth})
+			termStart = -1
+		}
+	}
+
+	for i, c := range search {
+		switch {
+		case escaped:
+			escaped = false
+		case inQuotes:
+			if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inQuotes = false
+			}
+		case unicode.IsSpace(c):
+			endTerm(i)
+		case c == '|' || c == '[' || c == ']':
+			endTerm(i)
+			token := splToken{kind: splPipe, text: string(c), start: i, end: i + 1, depth: depth}
+			switch c {
+			case '[':
+				token.kind = splOpenBracket
+				depth++
+			case ']':
+				token.kind = splCloseBracket
+				if depth > 0 {
+					depth--
+				}
+				token.depth = depth
+			}
+			tokens = append(tokens, token)
+		default:
+			if termStart == -1 {
+				termStart = i
+			}
+			if c == '"' {
+				inQuotes = true
+			}
+		}
+	}
+	endTerm(len(search))
+
+	return tokens
+}
+
+// return the terms of the base search, i.e. before the first pipe which is not in a subsearch, apart from those of its subsearches
+func baseSearchTerms(tokens []splToken) []splToken {
+	var terms []splToken
+	for _, token := range tokens {
+		if token.depth > 0 {
+			continue
+		}
+		if token.kind == splPipe {
+			break
+		}
+		if token.kind == splTerm {
+			terms = append(terms, token)
+		}
+	}
+	return terms
+}
+
+// BaseSearch returns the base search of the query, before its first pipe which is not in a subsearch, empty for a generating command
+func BaseSearch(query string) string {
+	if pipe := firstTopLevelPipe(query); pipe != -1 {
+		query = query[:pipe]
+	}
+	return strings.TrimSpace(query)
+}
+
+// return the index of the first pipe of the search which is neither in a quoted string nor in a subsearch, -1 if there is none
+func firstTopLevelPipe(search string) int {
+	for _, token := range lexSPL(search) {
+		if token.kind == splPipe && token.depth == 0 {
+			return token.start
+		}
+	}
+	return -1
+}
+
+// remove the quotes and escapes of a quoted value
+func unquoteSPL(value string) string {
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return value
+	}
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+}
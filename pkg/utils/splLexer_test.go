@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexSPL(t *testing.T) {
+	tokens := lexSPL(`index=main msg="a | [b]" [search host="x\"]" | head 1] | stats count`)
+
+	expected := []struct {
+		kind  splTokenKind
+		text  string
+		depth int
+	}{
+		{splTerm, "index=main", 0},
+		{splTerm, `msg="a | [b]"`, 0},
+		{splOpenBracket, "[", 0},
+		{splTerm, "search", 1},
+		{splTerm, `host="x\"]"`, 1},
+		{splPipe, "|", 1},
+		{splTerm, "head", 1},
+		{splTerm, "1", 1},
+		{splCloseBracket, "]", 0},
+		{splPipe, "|", 0},
+		{splTerm, "stats", 0},
+		{splTerm, "count", 0},
+	}
+	if len(tokens) != len(expected) {
+		t.Fatalf("Expected %d tokens but got %+v", len(expected), tokens)
+	}
+	for i, token := range tokens {
+		if token.kind != expected[i].kind || token.text != expected[i].text || token.depth != expected[i].depth {
+			t.Fatalf("Expected %+v at position %d but got %+v", expected[i], i, token)
+		}
+	}
+
+	search := `index=main msg="a | [b]" [search host="x\"]" | head 1] | stats count`
+	if pipe := firstTopLevelPipe(search); pipe != strings.Index(search, "| stats") {
+		t.Fatalf("Expected the first top level pipe before stats but got %d", pipe)
+	}
+	if unquoted := unquoteSPL(`"say \"hi\" \\ "`); unquoted != `say "hi" \ ` {
+		t.Fatalf("Expected the value to be unquoted but got %s", unquoted)
+	}
+}
+
+func TestBaseSearch(t *testing.T) {
+	tests := map[string]string{
+		`index=main error | stats count`:                        "index=main error",
+		`index=main [search host="a|b" | head 1] | stats count`: `index=main [search host="a|b" | head 1]`,
+		` index=main error `:                                    "index=main error",
+		`| tstats count where index=main`:                       "",
+	}
+	for query, expected := range tests {
+		if base := BaseSearch(query); base != expected {
+			t.Errorf("Expected the base search of %s to be %q but got %q", query, expected, base)
+		}
+	}
+}
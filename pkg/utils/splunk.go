@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"time"
 
@@ -13,13 +16,23 @@ import (
 	logger "github.com/sirupsen/logrus"
 )
 
+// authentication methods which can be set in SP_AUTH_METHOD
+const (
+	AuthMethodToken      = "token"
+	AuthMethodSessionKey = "sessionKey"
+	AuthMethodBasic      = "basic"
+	AuthMethodLogin      = "login"
+)
+
 type SplunkCredentials struct {
+	URL        string `json:"url" yaml:"spUrl"`
 	Host       string `json:"host" yaml:"spHost"`
 	Port       string `json:"port" yaml:"spPort"`
 	Username   string `json:"username" yaml:"spUsername"`
 	Password   string `json:"password" yaml:"spPassword"`
 	Token      string `json:"token" yaml:"spApiToken"`
 	SessionKey string `json:"sessionKey" yaml:"spSessionKey"`
+	AuthMethod string `json:"authMethod" yaml:"spAuthMethod"`
 }
 
 // getSplunkCredentials get the splunk host, port and api token from the environment variables set from secret
@@ -27,22 +40,34 @@ func GetSplunkCredentials(env EnvConfig) (*SplunkCredentials, error) {
 
 	logger.Info("Trying to retrieve splunk credentials ...")
 	splunkCreds := SplunkCredentials{}
+
+	err := checkAuthMethod(env)
+	if err != nil {
+		return nil, err
+	}
+	err = checkSplunkURL(env.SplunkURL)
+	if err != nil {
+		return nil, err
+	}
+
 	switch {
-	case env.SplunkHost != "" && env.SplunkPort != "" && (env.SplunkApiToken != "" || (env.SplunkUsername != "" && env.SplunkPassword != "") || env.SplunkSessionKey != ""):
+	case (env.SplunkURL != "" || env.SplunkHost != "" && env.SplunkPort != "") && (env.SplunkApiToken != "" || (env.SplunkUsername != "" && env.SplunkPassword != "") || env.SplunkSessionKey != ""):
+		splunkCreds.URL = env.SplunkURL
 		splunkCreds.Host = strings.ReplaceAll(env.SplunkHost, " ", "")
 		splunkCreds.Token = env.SplunkApiToken
 		splunkCreds.Port = env.SplunkPort
 		splunkCreds.Username = env.SplunkUsername
 		splunkCreds.Password = env.SplunkPassword
 		splunkCreds.SessionKey = env.SplunkSessionKey
+		splunkCreds.AuthMethod = env.SplunkAuthMethod
 
 		logger.Info("Successfully retrieved splunk credentials")
 
 	default:
-		if env.SplunkHost == "" {
-			logger.Error("SP_HOST not set")
+		if env.SplunkURL == "" && env.SplunkHost == "" {
+			logger.Error("SP_URL and SP_HOST not set")
 		}
-		if env.SplunkPort == "" {
+		if env.SplunkURL == "" && env.SplunkPort == "" {
 			logger.Error("SP_PORT not set")
 		}
 		if env.SplunkApiToken == "" {
@@ -54,18 +79,103 @@ func GetSplunkCredentials(env EnvConfig) (*SplunkCredentials, error) {
 		if env.SplunkSessionKey == "" {
 			logger.Error("SP_SESSION_KEY not set")
 		}
-		return nil, fmt.Errorf("invalid credentials found in SP_HOST, SP_PORT, SP_HOST, SP_API_TOKEN, SP_USERNAME, SP_PASSWORD and/or SP_SESSION_KEY")
+		return nil, fmt.Errorf("invalid credentials found in SP_URL, SP_HOST, SP_PORT, SP_API_TOKEN, SP_USERNAME, SP_PASSWORD and/or SP_SESSION_KEY")
 	}
 
 	return &splunkCreds, nil
 }
 
+// check that the credentials required by the authentication method set in SP_AUTH_METHOD are provided
+func checkAuthMethod(env EnvConfig) error {
+	switch env.SplunkAuthMethod {
+	case "":
+		return nil
+	case AuthMethodToken:
+		if env.SplunkApiToken == "" {
+			return fmt.Errorf("SP_API_TOKEN has to be set when SP_AUTH_METHOD is %s", env.SplunkAuthMethod)
+		}
+	case AuthMethodSessionKey:
+		if env.SplunkSessionKey == "" {
+			return fmt.Errorf("SP_SESSION_KEY has to be set when SP_AUTH_METHOD is %s", env.SplunkAuthMethod)
+		}
+	case AuthMethodBasic, AuthMethodLogin:
+		if env.SplunkUsername == "" || env.SplunkPassword == "" {
+			return fmt.Errorf("SP_USERNAME and SP_PASSWORD have to be set when SP_AUTH_METHOD is %s", env.SplunkAuthMethod)
+		}
+	default:
+		return fmt.Errorf("unknown authentication method in SP_AUTH_METHOD : %s", env.SplunkAuthMethod)
+	}
+	return nil
+}
+
+// Returns the tls configuration of the connection to splunk
+func GetTLSConfig(env EnvConfig) (*tls.Config, error) {
+	if env.SplunkSkipTLSVerify {
+		logger.Warn("SP_SKIP_TLS_VERIFY is set, the certificate of splunk will not be verified")
+	}
+	return splunk.NewTLSConfig(splunk.TLSOptions{
+		InsecureSkipVerify: env.SplunkSkipTLSVerify,
+		CABundlePath:       env.SplunkCABundle,
+		ClientCertPath:     env.SplunkClientCert,
+		ClientKeyPath:      env.SplunkClientKey,
+		ServerName:         env.SplunkTLSServerName,
+		MinVersion:         env.SplunkTLSMinVersion,
+	})
+}
+
+// check that the url set in SP_URL is an absolute http or https url
+func checkSplunkURL(splunkURL string) error {
+	if splunkURL == "" {
+		return nil
+	}
+	parsedURL, err := url.Parse(splunkURL)
+	if err != nil {
+		return fmt.Errorf("invalid url in SP_URL : %w", err)
+	}
+	if (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
+		return fmt.Errorf("invalid url in SP_URL : %s, should be like https://host:port/path", splunkURL)
+	}
+	return nil
+}
+
 // Creates an authenticated splunk client
-func ConnectToSplunk(splunkCreds SplunkCredentials, skipSSL bool) *splunk.SplunkClient {
+func ConnectToSplunk(splunkCreds SplunkCredentials, tlsConfig *tls.Config) *splunk.SplunkClient {
 
 	logger.Info("Connecting to Splunk ...")
 	var client *splunk.SplunkClient
 	switch {
+	case splunkCreds.AuthMethod == AuthMethodLogin:
+		client = splunk.NewClientAuthenticatedByLogin(
+			&http.Client{
+				Timeout: time.Duration(60) * time.Second,
+			},
+			splunkCreds.Host,
+			splunkCreds.Port,
+			splunkCreds.Username,
+			splunkCreds.Password,
+			tlsConfig,
+		)
+	case splunkCreds.AuthMethod == AuthMethodBasic:
+		client = splunk.NewBasicAuthenticatedClient(
+			&http.Client{
+				Timeout: time.Duration(60) * time.Second,
+			},
+			splunkCreds.Host,
+			splunkCreds.Port,
+			splunkCreds.Username,
+			splunkCreds.Password,
+			tlsConfig,
+		)
+	case splunkCreds.AuthMethod == AuthMethodSessionKey:
+		client = splunk.NewClientAuthenticatedBySessionKey(
+			&http.Client{
+				Timeout: time.Duration(60) * time.Second,
+			},
+			splunkCreds.Host,
+			splunkCreds.Port,
+			splunkCreds.SessionKey,
+			tlsConfig,
+		)
 	case splunkCreds.Token != "":
 		client = splunk.NewClientAuthenticatedByToken(
 			&http.Client{
@@ -74,7 +184,7 @@ func ConnectToSplunk(splunkCreds SplunkCredentials, skipSSL bool) *splunk.Splunk
 			splunkCreds.Host,
 			splunkCreds.Port,
 			splunkCreds.Token,
-			skipSSL,
+			tlsConfig,
 		)
 	case splunkCreds.SessionKey != "":
 		client = splunk.NewClientAuthenticatedBySessionKey(
@@ -84,7 +194,7 @@ func ConnectToSplunk(splunkCreds SplunkCredentials, skipSSL bool) *splunk.Splunk
 			splunkCreds.Host,
 			splunkCreds.Port,
 			splunkCreds.SessionKey,
-			skipSSL,
+			tlsConfig,
 		)
 	default:
 		client = splunk.NewBasicAuthenticatedClient(
@@ -95,13 +205,73 @@ func ConnectToSplunk(splunkCreds SplunkCredentials, skipSSL bool) *splunk.Splunk
 			splunkCreds.Port,
 			splunkCreds.Username,
 			splunkCreds.Password,
-			skipSSL,
+			tlsConfig,
 		)
 	}
+	client.BaseURL = splunkCreds.URL
 
 	return client
 }
 
+// Returns the retry policy to apply to the requests sent to splunk
+func GetRetryPolicy(env EnvConfig) *splunk.RetryPolicy {
+	policy := splunk.DefaultRetryPolicy()
+	policy.MaxAttempts = env.RetryMaxAttempts
+	policy.InitialBackoff = env.RetryInitialBackoff
+	policy.MaxBackoff = env.RetryMaxBackoff
+	policy.Jitter = env.RetryJitter
+	policy.RetryableStatusCodes = env.RetryableStatusCodes
+
+	return policy
+}
+
+// Returns the splunk namespace of the requests concerning the project, the global one if none is set for the project
+func GetNamespace(env EnvConfig, project string) splunk.Namespace {
+	if projectNamespace, ok := env.SplunkProjectNamespaces[project]; ok && project != "" {
+		namespace, err := parseNamespace(projectNamespace)
+		if err == nil {
+			return namespace
+		}
+		logger.Errorf("Ignoring the namespace of project %s : %v", project, err)
+	}
+	return splunk.Namespace{Owner: env.SplunkOwner, App: env.SplunkApp}
+}
+
+// Returns a context whose requests see the alerts of all the namespaces used by the service
+func WithAlertsNamespace(ctx context.Context, env EnvConfig) context.Context {
+	if len(env.SplunkProjectNamespaces) == 0 {
+		return ctx
+	}
+	return splunk.WithNamespace(ctx, splunk.Namespace{Owner: "-", App: "-"})
+}
+
+// Checks the namespaces set in SP_PROJECT_NAMESPACES and the sharing of the alerts
+func CheckNamespaces(env EnvConfig) error {
+	for project, projectNamespace := range env.SplunkProjectNamespaces {
+		if _, err := parseNamespace(projectNamespace); err != nil {
+			return fmt.Errorf("invalid namespace for project %s in SP_PROJECT_NAMESPACES : %w", project, err)
+		}
+	}
+	switch env.AlertSharing {
+	case "", "user", "app", "global":
+	default:
+		return fmt.Errorf("invalid ALERT_SHARING %s, should be one of user, app or global", env.AlertSharing)
+	}
+	return nil
+}
+
+// parse a namespace written as owner/app or app
+func parseNamespace(namespace string) (splunk.Namespace, error) {
+	parts := strings.Split(strings.TrimSpace(namespace), "/")
+	switch {
+	case len(parts) == 1 && parts[0] != "":
+		return splunk.Namespace{App: parts[0]}, nil
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return splunk.Namespace{Owner: parts[0], App: parts[1]}, nil
+	}
+	return splunk.Namespace{}, fmt.Errorf("%s should be written as owner/app or app", namespace)
+}
+
 // Build a mock splunk server returning default responses when getting  get and post requests
 func BuildMockSplunkServer(splunkResult float64) *httptest.Server {
 
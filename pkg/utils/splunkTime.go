@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var epochRegex = regexp.MustCompile(`^[0-9]+(\.[0-9]+)?$`)
+
+// layouts of the absolute times, in addition to epoch times. The layouts without zone are read in the location of now
+var absoluteTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	// default time format of splunk
+	"01/02/2006:15:04:05",
+}
+
+// ParseSplunkTime evaluates a splunk time modifier relative to now
+// the modifier is either now, an epoch time, an ISO 8601 or %m/%d/%Y:%H:%M:%S timestamp, or a chain of relative offsets and snaps
+// like -1d@d+8h, the snaps being done in the location of now
+func ParseSplunkTime(modifier string, now time.Time) (time.Time, error) {
+	modifier = strings.TrimSpace(modifier)
+	if modifier == "" || modifier == "now" {
+		return now, nil
+	}
+	if epochRegex.MatchString(modifier) {
+		return parseEpochTime(modifier, now.Location())
+	}
+	for _, layout := range absoluteTimeLayouts {
+		if t, err := time.ParseInLocation(layout, modifier, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+	if strings.HasPrefix(modifier, "rt") {
+		return time.Time{}, fmt.Errorf("invalid time %s : real-time searches are not supported", modifier)
+	}
+
+	t := now
+	for pos := 0; pos < len(modifier); {
+		switch modifier[pos] {
+		case '+', '-':
+			sign := 1
+			if modifier[pos] == '-' {
+				sign = -1
+			}
+			pos++
+			digits := readWhile(modifier, pos, isDigit)
+			pos += len(digits)
+			unit := readWhile(modifier, pos, isLetter)
+			pos += len(unit)
+
+			amount := 1
+			if digits != "" {
+				amount, _ = strconv.Atoi(digits)
+			}
+			var err error
+			t, err = addTimeOffset(t, sign*amount, unit)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid time %s : %w", modifier, err)
+			}
+		case '@':
+			pos++
+			unit := readWhile(modifier, pos, isLetter)
+			pos += len(unit)
+			// @w0 to @w7 snap to a day of the week
+			if unit == "w" && pos < len(modifier) && isDigit(modifier[pos]) {
+				unit += modifier[pos : pos+1]
+				pos++
+			}
+			var err error
+			t, err = snapTime(t, unit)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid time %s : %w", modifier, err)
+			}
+		default:
+			return time.Time{}, fmt.Errorf("invalid time %s : expected now, an epoch time, a timestamp or relative modifiers like -1h@h", modifier)
+		}
+	}
+	return t, nil
+}
+
+// ValidateSplunkTime returns an error if the splunk time modifier cannot be evaluated
+func ValidateSplunkTime(modifier string) error {
+	_, err := ParseSplunkTime(modifier, time.Now())
+	return err
+}
+
+// ToEpochTime returns the epoch time in seconds of the splunk time modifier, evaluated relative to now
+// the fraction of second is kept up to the microsecond, the precision of splunk
+func ToEpochTime(modifier string, now time.Time) (string, error) {
+	t, err := ParseSplunkTime(modifier, now)
+	if err != nil {
+		return "", err
+	}
+	return FormatEpochTime(t), nil
+}
+
+// FormatEpochTime returns the epoch time in seconds of the time, with its fraction of second up to the microsecond
+func FormatEpochTime(t time.Time) string {
+	epoch := strconv.FormatInt(t.Unix(), 10)
+	if microseconds := t.Nanosecond() / 1000; microseconds != 0 {
+		epoch += strings.TrimRight(fmt.Sprintf(".%06d", microseconds), "0")
+	}
+	return epoch
+}
+
+// NormalizeSplunkTime returns the epoch time of an absolute time, like the ISO 8601 start and end of an evaluation,
+// so that splunk searches exactly the same time range whatever its time format and timezone settings
+// the timestamps without timezone are read in the given location, UTC if it is nil
+// relative modifiers are evaluated at now in the given location if there is one, otherwise they are returned unchanged
+// and splunk evaluates them in the timezone of its user when the search is dispatched
+func NormalizeSplunkTime(modifier string, location *time.Location, now time.Time) (string, error) {
+	if err := ValidateSplunkTime(modifier); err != nil {
+		return "", err
+	}
+	if location == nil {
+		if !isAbsoluteTime(modifier) {
+			return modifier, nil
+		}
+		location = time.UTC
+	}
+	return ToEpochTime(modifier, now.In(location))
+}
+
+func isAbsoluteTime(modifier string) bool {
+	modifier = strings.TrimSpace(modifier)
+	if epochRegex.MatchString(modifier) {
+		return true
+	}
+	for _, layout := range absoluteTimeLayouts {
+		if _, err := time.Parse(layout, modifier); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// parse an epoch time in seconds without losing the precision of its fraction
+func parseEpochTime(epoch string, location *time.Location) (time.Time, error) {
+	seconds, fraction, _ := strings.Cut(epoch, ".")
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid epoch time %s : %w", epoch, err)
+	}
+	nsec := int64(0)
+	if fraction != "" {
+		if len(fraction) > 9 {
+			fraction = fraction[:9]
+		}
+		nsec, _ = strconv.ParseInt(fraction+strings.Repeat("0", 9-len(fraction)), 10, 64)
+	}
+	return time.Unix(sec, nsec).In(location), nil
+}
+
+// return the normalized name of a time unit of splunk
+func timeUnit(unit string) (string, error) {
+	switch unit {
+	case "s", "sec", "secs", "second", "seconds":
+		return "s", nil
+	case "m", "min", "mins", "minute", "minutes":
+		return "m", nil
+	case "h", "hr", "hrs", "hour", "hours":
+		return "h", nil
+	case "d", "day", "days":
+		return "d", nil
+	case "w", "week", "weeks":
+		return "w", nil
+	case "mon", "month", "months":
+		return "mon", nil
+	case "q", "qtr", "qtrs", "quarter", "quarters":
+		return "q", nil
+	case "y", "yr", "yrs", "year", "years":
+		return "y", nil
+	}
+	return "", fmt.Errorf("unknown time unit %q", unit)
+}
+
+func addTimeOffset(t time.Time, amount int, unit string) (time.Time, error) {
+	unit, err := timeUnit(unit)
+	if err != nil {
+		return t, err
+	}
+	switch unit {
+	case "s":
+		return t.Add(time.Duration(amount) * time.Second), nil
+	case "m":
+		return t.Add(time.Duration(amount) * time.Minute), nil
+	case "h":
+		return t.Add(time.Duration(amount) * time.Hour), nil
+	case "d":
+		return t.AddDate(0, 0, amount), nil
+	case "w":
+		return t.AddDate(0, 0, 7*amount), nil
+	case "mon":
+		return t.AddDate(0, amount, 0), nil
+	case "q":
+		return t.AddDate(0, 3*amount, 0), nil
+	}
+	return t.AddDate(amount, 0, 0), nil
+}
+
+// snap the time to the beginning of the given unit, or to the latest given day of the week for w0 (sunday) to w7 (sunday)
+func snapTime(t time.Time, unit string) (time.Time, error) {
+	if len(unit) == 2 && unit[0] == 'w' && isDigit(unit[1]) {
+		if unit[1] > '7' {
+			return t, fmt.Errorf("unknown day of the week %q", unit)
+		}
+		weekday := int(unit[1]-'0') % 7
+		midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return midnight.AddDate(0, 0, -((int(t.Weekday()) - weekday + 7) % 7)), nil
+	}
+
+	unit, err := timeUnit(unit)
+	if err != nil {
+		return t, err
+	}
+	switch unit {
+	case "s":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, t.Location()), nil
+	case "m":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, t.Location()), nil
+	case "h":
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()), nil
+	case "d":
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()), nil
+	case "w":
+		return snapTime(t, "w0")
+	case "mon":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()), nil
+	case "q":
+		return time.Date(t.Year(), t.Month()-(t.Month()-1)%3, 1, 0, 0, 0, 0, t.Location()), nil
+	}
+	return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location()), nil
+}
+
+func readWhile(s string, pos int, accept func(byte) bool) string {
+	end := pos
+	for end < len(s) && accept(s[end]) {
+		end++
+	}
+	return s[pos:end]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z'
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSplunkTime(t *testing.T) {
+	// a wednesday
+	now := time.Date(2023, time.July, 19, 10, 42, 30, 0, time.UTC)
+
+	tests := []struct {
+		modifier string
+		expected time.Time
+	}{
+		{"now", now},
+		{"", now},
+		{"1689674400", time.Date(2023, time.July, 18, 10, 0, 0, 0, time.UTC)},
+		{"1689674400.500", time.Date(2023, time.July, 18, 10, 0, 0, 500000000, time.UTC)},
+		{"2023-07-18T10:00:00.000Z", time.Date(2023, time.July, 18, 10, 0, 0, 0, time.UTC)},
+		{"2023-07-18T12:00:00+02:00", time.Date(2023, time.July, 18, 10, 0, 0, 0, time.UTC)},
+		{"07/18/2023:10:00:00", time.Date(2023, time.July, 18, 10, 0, 0, 0, time.UTC)},
+		{"-3m", now.Add(-3 * time.Minute)},
+		{"+90s", now.Add(90 * time.Second)},
+		{"-mon", time.Date(2023, time.June, 19, 10, 42, 30, 0, time.UTC)},
+		{"@h", time.Date(2023, time.July, 19, 10, 0, 0, 0, time.UTC)},
+		{"-1d@d", time.Date(2023, time.July, 18, 0, 0, 0, 0, time.UTC)},
+		{"-1d@d+8h", time.Date(2023, time.July, 18, 8, 0, 0, 0, time.UTC)},
+		{"@w0", time.Date(2023, time.July, 16, 0, 0, 0, 0, time.UTC)},
+		{"@w1", time.Date(2023, time.July, 17, 0, 0, 0, 0, time.UTC)},
+		{"@w3", time.Date(2023, time.July, 19, 0, 0, 0, 0, time.UTC)},
+		{"@w5", time.Date(2023, time.July, 14, 0, 0, 0, 0, time.UTC)},
+		{"@q", time.Date(2023, time.July, 1, 0, 0, 0, 0, time.UTC)},
+		{"-1y@y", time.Date(2022, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"-2hours@minute", time.Date(2023, time.July, 19, 8, 42, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		parsed, err := ParseSplunkTime(test.modifier, now)
+		if err != nil || !parsed.Equal(test.expected) {
+			t.Fatalf("%s : expected %v but got %v, %v", test.modifier, test.expected, parsed, err)
+		}
+	}
+
+	for _, modifier := range []string{"*/1 * * * *", "-3", "-3x", "@w8", "rt-5m", "yesterday", "-1h@"} {
+		if _, err := ParseSplunkTime(modifier, now); err == nil {
+			t.Fatalf("Expected %q to be invalid", modifier)
+		}
+	}
+}
+
+func TestNormalizeSplunkTime(t *testing.T) {
+	tests := map[string]string{
+		"2021-01-15T15:04:45.000Z":       "1610723085",
+		"2021-01-15T15:04:45.250Z":       "1610723085.25",
+		"2021-01-15T15:04:45.123456789Z": "1610723085.123456",
+		"2021-01-15T15:04:45":            "1610723085",
+		"1610723085.000001":              "1610723085.000001",
+		"1610723085":                     "1610723085",
+		"-1h@h":                          "-1h@h",
+		"now":                            "now",
+	}
+	for modifier, expected := range tests {
+		normalized, err := NormalizeSplunkTime(modifier, nil, time.Now())
+		if err != nil || normalized != expected {
+			t.Fatalf("%s : expected %s but got %s, %v", modifier, expected, normalized, err)
+		}
+	}
+
+	if _, err := NormalizeSplunkTime("*/1 * * * *", nil, time.Now()); err == nil {
+		t.Fatal("Expected an error for a cron expression")
+	}
+}
+
+// Tests that the snaps and the timestamps without timezone follow the configured timezone
+func TestSplunkTimeInTimezone(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		t.Fatalf("Got an error : %s", err)
+	}
+	// 00:30 in Paris, still the previous day in UTC
+	now := time.Date(2023, time.July, 18, 22, 30, 0, 0, time.UTC).In(paris)
+
+	parsed, err := ParseSplunkTime("-1d@d", now)
+	if err != nil || !parsed.Equal(time.Date(2023, time.July, 17, 22, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected the midnight of the previous day in Paris but got %v, %v", parsed, err)
+	}
+	parsed, err = ParseSplunkTime("2023-07-18T10:00:00", now)
+	if err != nil || !parsed.Equal(time.Date(2023, time.July, 18, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Expected the timestamp to be read in Paris but got %v, %v", parsed, err)
+	}
+
+	normalized, err := NormalizeSplunkTime("-1h@h", paris, time.Now())
+	if err != nil || !epochRegex.MatchString(normalized) {
+		t.Fatalf("Expected the relative modifier to be evaluated in Paris but got %s, %v", normalized, err)
+	}
+	normalized, err = NormalizeSplunkTime("2023-07-18T10:00:00", paris, time.Now())
+	if err != nil || normalized != "1689667200" {
+		t.Fatalf("Expected the timestamp to be read in Paris but got %s, %v", normalized, err)
+	}
+	normalized, err = NormalizeSplunkTime("2023-07-18T10:00:00.000Z", paris, time.Now())
+	if err != nil || normalized != "1689674400" {
+		t.Fatalf("Expected the timezone of the timestamp to be kept but got %s, %v", normalized, err)
+	}
+}
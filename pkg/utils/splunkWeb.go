@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// app of the links when the search does not run in a specific app
+const defaultWebApp = "search"
+
+// SearchLink returns the link to splunk web running the query over the time range, empty if there is no splunk web url
+func SearchLink(webURL string, app string, query string, earliest string, latest string) string {
+	if webURL == "" {
+		return ""
+	}
+	params := url.Values{}
+	params.Set("q", query)
+	if earliest != "" {
+		params.Set("earliest", earliest)
+	}
+	if latest != "" {
+		params.Set("latest", latest)
+	}
+	return webAppURL(webURL, app) + "/search?" + params.Encode()
+}
+
+// JobLink returns the link to splunk web showing the results of the search job, empty if there is no splunk web url
+// splunk keeps the results of a job only until it expires, 10 minutes after its completion by default
+func JobLink(webURL string, app string, sid string) string {
+	if webURL == "" {
+		return ""
+	}
+	return webAppURL(webURL, app) + "/search?" + url.Values{"sid": {sid}}.Encode()
+}
+
+// ValidateWebURL returns an error if the url of splunk web is neither empty nor an absolute http(s) url
+func ValidateWebURL(webURL string) error {
+	if webURL == "" {
+		return nil
+	}
+	parsed, err := url.Parse(webURL)
+	if err != nil {
+		return fmt.Errorf("invalid splunk web url %s : %w", webURL, err)
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("invalid splunk web url %s, expected an absolute http or https url like https://splunk:8000", webURL)
+	}
+	return nil
+}
+
+func webAppURL(webURL string, app string) string {
+	if app == "" {
+		app = defaultWebApp
+	}
+	return strings.TrimSuffix(webURL, "/") + "/app/" + url.PathEscape(app)
+}
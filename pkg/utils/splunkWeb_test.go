@@ -0,0 +1,29 @@
+package utils
+
+import (
+	"testing"
+)
+
+func TestSplunkWebLinks(t *testing.T) {
+	link := SearchLink("https://splunk:8000/", "", `index=main "error" | stats count`, "1689674400", "1689674700")
+	expected := "https://splunk:8000/app/search/search?earliest=1689674400&latest=1689674700&q=index%3Dmain+%22error%22+%7C+stats+count"
+	if link != expected {
+		t.Fatalf("Expected %s but got %s", expected, link)
+	}
+
+	link = JobLink("https://splunk.example.com/en-US", "web", "scheduler__admin__search__RMD5fee5950320bcf753_at_1689080400_135")
+	expected = "https://splunk.example.com/en-US/app/web/search?sid=scheduler__admin__search__RMD5fee5950320bcf753_at_1689080400_135"
+	if link != expected {
+		t.Fatalf("Expected %s but got %s", expected, link)
+	}
+
+	if SearchLink("", "", "index=main", "", "") != "" || JobLink("", "", "1689673231.191") != "" {
+		t.Fatal("Expected no link without splunk web url")
+	}
+
+	for _, webURL := range []string{"splunk:8000", "/splunk", "ftp://splunk"} {
+		if err := ValidateWebURL(webURL); err == nil {
+			t.Fatalf("Expected an error for the splunk web url %s", webURL)
+		}
+	}
+}
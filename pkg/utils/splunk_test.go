@@ -4,6 +4,8 @@ import (
 	"os"
 	"testing"
 
+	splunk "github.com/keptn-sandbox/splunk-sli-provider/pkg/splunksdk/client"
+
 	"github.com/joho/godotenv"
 )
 
@@ -32,3 +34,64 @@ func TestGetSplunkCredentials(t *testing.T) {
 		t.Logf("Received expected error : %v", err)
 	}
 }
+
+// Tests that a full url can be used instead of SP_HOST and SP_PORT
+func TestGetSplunkCredentialsWithURL(t *testing.T) {
+	env := EnvConfig{
+		SplunkURL:      "https://gateway/splunk-api/",
+		SplunkApiToken: "splunkApiToken",
+	}
+
+	sp, err := GetSplunkCredentials(env)
+	if err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	client := ConnectToSplunk(*sp, nil)
+	if client.BaseURL != "https://gateway/splunk-api/" {
+		t.Fatalf("Expected the url to be used by the client but got %v", client.BaseURL)
+	}
+
+	for _, invalidURL := range []string{"gateway/splunk-api", "ftp://gateway", "https://"} {
+		env.SplunkURL = invalidURL
+		if _, err := GetSplunkCredentials(env); err == nil {
+			t.Fatalf("Expected an error for the url %s", invalidURL)
+		}
+	}
+}
+
+// Tests the namespaces set globally and per project
+func TestGetNamespace(t *testing.T) {
+	env := EnvConfig{
+		SplunkOwner: "admin",
+		SplunkApp:   "keptn",
+		SplunkProjectNamespaces: map[string]string{
+			"sockshop":  "svc/sockshop",
+			"podtato":   "podtato",
+			"malformed": "a/b/c",
+		},
+	}
+
+	tests := map[string]splunk.Namespace{
+		"sockshop":  {Owner: "svc", App: "sockshop"},
+		"podtato":   {App: "podtato"},
+		"other":     {Owner: "admin", App: "keptn"},
+		"malformed": {Owner: "admin", App: "keptn"},
+	}
+	for project, expected := range tests {
+		if namespace := GetNamespace(env, project); namespace != expected {
+			t.Fatalf("Project %s : expected %v but got %v", project, expected, namespace)
+		}
+	}
+
+	if err := CheckNamespaces(env); err == nil {
+		t.Fatalf("Expected an error for the malformed namespace")
+	}
+	delete(env.SplunkProjectNamespaces, "malformed")
+	if err := CheckNamespaces(env); err != nil {
+		t.Fatalf("Got an error : %v", err)
+	}
+	env.AlertSharing = "everyone"
+	if err := CheckNamespaces(env); err == nil {
+		t.Fatalf("Expected an error for an unknown sharing")
+	}
+}
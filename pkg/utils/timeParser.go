@@ -4,36 +4,33 @@ import (
 	"strings"
 )
 
-// check if the search string contains the earliest or latest time and return the time and the query
+// return the value of the first time modifier of the given kind (earliest or latest) of the base search and the search without these modifiers
+// the modifiers of the subsearches, in quoted strings or after the first pipe are not time modifiers of the search and are kept
 func getQueryTime(kind string, searchQuery string, defaultTime string) (string, string) {
-	if !strings.Contains(searchQuery, kind) {
-		return defaultTime, searchQuery
-	}
-	startIndex := strings.Index(searchQuery, kind)
-	q1 := strings.Fields(searchQuery[startIndex:])
-
-	timeValue := ""
-	if !strings.HasPrefix(q1[0][len(kind)+1:], "\"") {
-		timeValue = q1[0][len(kind)+1:]
-		searchQuery = strings.ReplaceAll(searchQuery, q1[0], "")
-
-		return strings.TrimSuffix(timeValue, "\""), searchQuery
-	}
-	for i, v := range q1 {
-		switch i {
-		case 0:
-			timeValue += v[len(kind)+2:]
-		default:
-			timeValue += " " + v
+	timeValue := defaultTime
+	found := false
+
+	var sb strings.Builder
+	last := 0
+	for _, term := range baseSearchTerms(lexSPL(searchQuery)) {
+		value, ok := strings.CutPrefix(term.text, kind+"=")
+		if !ok {
+			continue
+		}
+		if !found {
+			timeValue = unquoteSPL(value)
+			found = true
 		}
-		if strings.HasSuffix(v, "\"") {
-			break
+		// the other modifiers of the same kind are removed so that they do not override the extracted one
+		sb.WriteString(searchQuery[last:term.start])
+		last = term.end
+		for last < len(searchQuery) && searchQuery[last] == ' ' {
+			last++
 		}
 	}
-	searchQuery = strings.ReplaceAll(searchQuery, timeValue, "")
-	searchQuery = strings.ReplaceAll(searchQuery, kind+"=\"", "")
+	sb.WriteString(searchQuery[last:])
 
-	return strings.TrimSuffix(timeValue, "\""), searchQuery
+	return timeValue, sb.String()
 }
 
 // get the earliest, latest time from the splunk search and also update the search query
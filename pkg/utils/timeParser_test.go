@@ -58,3 +58,26 @@ func checkRetrieveSearchTimeRange(t *testing.T, splunkRequestParams *splunkjob.S
 	}
 
 }
+
+// Tests that only the time modifiers of the base search are extracted
+func TestRetrieveQueryTimeRangeTopLevelOnly(t *testing.T) {
+	tests := []struct {
+		query            string
+		expectedEarliest string
+		expectedLatest   string
+		expectedQuery    string
+	}{
+		{`index=main earliest_login=5 | stats count`, "-1m", "now", `index=main earliest_login=5 | stats count`},
+		{`index=main "latest=now is a message" | stats count`, "-1m", "now", `index=main "latest=now is a message" | stats count`},
+		{`index=main [search index=hosts earliest=-1d | fields host] | stats count`, "-1m", "now", `index=main [search index=hosts earliest=-1d | fields host] | stats count`},
+		{`index=main | where earliest=1 | stats count`, "-1m", "now", `index=main | where earliest=1 | stats count`},
+		{`index=main earliest="07/18/2023:10:00:00" latest=-5m [search index=hosts latest=-1d] | stats count`, "07/18/2023:10:00:00", "-5m", `index=main [search index=hosts latest=-1d] | stats count`},
+		{`index=main msg="say \"earliest=-1h\"" earliest=-2h`, "-2h", "now", `index=main msg="say \"earliest=-1h\"" `},
+	}
+	for _, test := range tests {
+		earliest, latest, query := RetrieveQueryTimeRange("-1m", "now", test.query)
+		if earliest != test.expectedEarliest || latest != test.expectedLatest || query != test.expectedQuery {
+			t.Fatalf("Expected %s, %s and %s but got %s, %s and %s", test.expectedEarliest, test.expectedLatest, test.expectedQuery, earliest, latest, query)
+		}
+	}
+}